@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+type AddressController struct {
+	addressService *services.AddressService
+	validate       *validator.Validate
+}
+
+func NewAddressController() *AddressController {
+	return &AddressController{
+		addressService: services.NewAddressService(),
+		validate:       validator.New(),
+	}
+}
+
+// @Summary List addresses
+// @Description Get the current user's address book, default addresses first
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} dto.AddressResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/auth/addresses [get]
+func (c *AddressController) GetAddresses(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	addresses, err := c.addressService.GetAddresses(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch addresses",
+		})
+	}
+
+	return ctx.JSON(convertAddressesToResponse(addresses))
+}
+
+// @Summary Get address
+// @Description Get one address from the current user's address book
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Address ID" minimum(1)
+// @Success 200 {object} dto.AddressResponse
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/auth/addresses/{id} [get]
+func (c *AddressController) GetAddress(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid address ID",
+		})
+	}
+
+	address, err := c.addressService.GetAddress(userID, uint(id))
+	if err != nil {
+		return addressErrorResponse(ctx, err, "Failed to fetch address")
+	}
+
+	return ctx.JSON(convertAddressToResponse(*address))
+}
+
+// @Summary Create address
+// @Description Add an address to the current user's address book. The country must be an ISO 3166-1 alpha-2 code and the postal code must match that country's format. The first address becomes the default shipping and billing address.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body dto.AddressRequest true "Address"
+// @Success 201 {object} dto.AddressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/auth/addresses [post]
+func (c *AddressController) CreateAddress(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req dto.AddressRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	address, err := c.addressService.CreateAddress(userID, req)
+	if err != nil {
+		return addressErrorResponse(ctx, err, "Failed to create address")
+	}
+
+	return ctx.Status(201).JSON(convertAddressToResponse(*address))
+}
+
+// @Summary Update address
+// @Description Update an address in the current user's address book. Only the given fields change; setting a default flag moves it from the previous default address.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Address ID" minimum(1)
+// @Param body body dto.UpdateAddressRequest true "Fields to update"
+// @Success 200 {object} dto.AddressResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/auth/addresses/{id} [put]
+func (c *AddressController) UpdateAddress(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid address ID",
+		})
+	}
+
+	var req dto.UpdateAddressRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	address, err := c.addressService.UpdateAddress(userID, uint(id), req)
+	if err != nil {
+		return addressErrorResponse(ctx, err, "Failed to update address")
+	}
+
+	return ctx.JSON(convertAddressToResponse(*address))
+}
+
+// @Summary Delete address
+// @Description Remove an address from the current user's address book. If it was a default address, the most recently added remaining address takes over.
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Address ID" minimum(1)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/auth/addresses/{id} [delete]
+func (c *AddressController) DeleteAddress(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid address ID",
+		})
+	}
+
+	if err := c.addressService.DeleteAddress(userID, uint(id)); err != nil {
+		return addressErrorResponse(ctx, err, "Failed to delete address")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Address deleted successfully",
+	})
+}
+
+// addressErrorResponse maps address book errors to HTTP responses
+func addressErrorResponse(ctx *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "Address not found",
+		})
+	case errors.Is(err, services.ErrInvalidCountry),
+		errors.Is(err, services.ErrInvalidPostalCode),
+		errors.Is(err, services.ErrAddressFieldsEmpty):
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrAddressBookFull):
+		return ctx.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": fallback,
+		})
+	}
+}
+
+// convertAddressToResponse maps an address model to the response DTO
+func convertAddressToResponse(address models.Address) dto.AddressResponse {
+	return dto.AddressResponse{
+		ID:              address.ID,
+		Label:           address.Label,
+		RecipientName:   address.RecipientName,
+		Line1:           address.Line1,
+		Line2:           address.Line2,
+		City:            address.City,
+		Region:          address.Region,
+		PostalCode:      address.PostalCode,
+		Country:         address.Country,
+		CountryName:     utils.CountryName(address.Country),
+		Phone:           address.Phone,
+		DefaultShipping: address.DefaultShipping,
+		DefaultBilling:  address.DefaultBilling,
+		CreatedAt:       address.CreatedAt,
+		UpdatedAt:       address.UpdatedAt,
+	}
+}
+
+func convertAddressesToResponse(addresses []models.Address) []dto.AddressResponse {
+	response := make([]dto.AddressResponse, len(addresses))
+	for i, address := range addresses {
+		response[i] = convertAddressToResponse(address)
+	}
+	return response
+}
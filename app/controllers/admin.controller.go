@@ -2,13 +2,16 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 
 	"runtime"
 
@@ -19,23 +22,23 @@ import (
 )
 
 type AdminController struct {
-	productService *services.ProductService
+	productService  *services.ProductService
+	categoryService *services.CategoryService
+	roleService     *services.RoleService
+	validate        *validator.Validate
 }
 
 func NewAdminController() *AdminController {
 	return &AdminController{
-		productService: services.NewProductService(),
+		productService:  services.NewProductService(),
+		categoryService: services.NewCategoryService(),
+		roleService:     services.NewRoleService(),
+		validate:        validator.New(),
 	}
 }
 
 // Helper function to convert product to response DTO
 func (c *AdminController) convertProductToResponse(product models.Product) dto.ProductResponse {
-	// Generate image URL
-	imageURL := ""
-	if product.Image != "" {
-		imageURL = fmt.Sprintf("/assets/images/Products/%s", product.Image)
-	}
-
 	response := dto.ProductResponse{
 		ID:               product.ID,
 		Index:            product.Index,
@@ -43,6 +46,7 @@ func (c *AdminController) convertProductToResponse(product models.Product) dto.P
 		Description:      product.Description,
 		ShortDescription: product.ShortDescription,
 		Brand:            product.Brand,
+		BrandID:          product.BrandID,
 		Category:         product.Category,
 		Price:            product.Price,
 		Currency:         product.Currency,
@@ -52,25 +56,41 @@ func (c *AdminController) convertProductToResponse(product models.Product) dto.P
 		Size:             product.Size,
 		Availability:     product.Availability,
 		Image:            product.Image,
-		ImageURL:         imageURL,
+		ImageURL:         productImageURL(product.Image),
 		InternalID:       product.InternalID,
 		Slug:             product.Slug,
 		SKU:              product.SKU,
+		GroupKey:         product.GroupKey,
+		Variants:         convertVariantsToResponses(product),
+		Images:           convertImagesToResponses(product.Images),
+		Attributes:       convertAttributesToResponses(product.Attributes),
 		Active:           product.Active,
 		CreatedAt:        product.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:        product.UpdatedAt.Format(time.RFC3339),
 	}
 
+	// A product with variants has the stock of its variants
+	if len(product.Variants) > 0 {
+		response.Stock = totalVariantStock(product.Variants)
+	}
+
+	if product.BrandModel != nil {
+		brand := convertBrandToResponse(*product.BrandModel)
+		response.BrandModel = &brand
+	}
+
 	// Add category model if available
 	if product.CategoryModel.ID != 0 {
 		response.CategoryModel = dto.CategoryResponse{
 			ID:          product.CategoryModel.ID,
+			ParentID:    product.CategoryModel.ParentID,
 			Name:        product.CategoryModel.Name,
 			Description: product.CategoryModel.Description,
 			Slug:        product.CategoryModel.Slug,
 			Active:      product.CategoryModel.Active,
 		}
 	}
+	response.Breadcrumbs = convertBreadcrumbs(product.Breadcrumbs)
 
 	return response
 }
@@ -136,7 +156,7 @@ func (c *AdminController) GetProducts(ctx *fiber.Ctx) error {
 	var err error
 
 	if search != "" {
-		products, total, err = c.productService.SearchProducts(search, "", "", "", "", "", page, limit)
+		products, total, err = c.productService.SearchProducts(search, "", "", "", "", "", page, limit, nil)
 	} else {
 		// For admin dashboard, always fetch fresh data without cache
 		products, total, err = c.productService.GetProductsWithoutCache(page, limit, categoryID)
@@ -170,7 +190,7 @@ func (c *AdminController) GetProducts(ctx *fiber.Ctx) error {
 }
 
 // @Summary Get categories for admin
-// @Description Get list of all categories for admin panel
+// @Description Get list of all categories for admin panel, inactive ones included, with the number of active products in each
 // @Tags admin
 // @Accept json
 // @Produce json
@@ -182,7 +202,7 @@ func (c *AdminController) GetCategories(ctx *fiber.Ctx) error {
 	_, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	categories, err := c.productService.GetCategories()
+	categories, err := c.categoryService.GetAdminCategories()
 	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{
 			"error": "Failed to fetch categories",
@@ -191,13 +211,7 @@ func (c *AdminController) GetCategories(ctx *fiber.Ctx) error {
 
 	var categoryResponses []dto.CategoryResponse
 	for _, category := range categories {
-		categoryResponses = append(categoryResponses, dto.CategoryResponse{
-			ID:          category.ID,
-			Name:        category.Name,
-			Description: category.Description,
-			Slug:        category.Slug,
-			Active:      category.Active,
-		})
+		categoryResponses = append(categoryResponses, convertCategoryToResponse(category))
 	}
 
 	return ctx.JSON(categoryResponses)
@@ -240,13 +254,14 @@ func (c *AdminController) GetProductByID(ctx *fiber.Ctx) error {
 }
 
 // @Summary Create new product
-// @Description Create a new product
+// @Description Create a new product, optionally with its variants
 // @Tags admin
 // @Accept json
 // @Produce json
 // @Param product body dto.CreateProductRequest true "Product data"
 // @Success 201 {object} dto.ProductResponse "Product created"
 // @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 409 {object} map[string]interface{} "Variant SKU already exists"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
 // @Router /admin/api/products [post]
 func (c *AdminController) CreateProduct(ctx *fiber.Ctx) error {
@@ -280,8 +295,29 @@ func (c *AdminController) CreateProduct(ctx *fiber.Ctx) error {
 		})
 	}
 
+	if err := c.validate.Struct(createRequest); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
 	// Create product using service
 	product, err := c.productService.CreateProduct(createRequest)
+	if errors.Is(err, services.ErrInvalidImageFile) {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, services.ErrInvalidAttribute) {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, services.ErrVariantSKUTaken) {
+		return ctx.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{
 			"error": "Failed to create product",
@@ -327,6 +363,11 @@ func (c *AdminController) UpdateProduct(ctx *fiber.Ctx) error {
 
 	// Update product using service
 	product, err := c.productService.UpdateProduct(uint(id), updateRequest)
+	if errors.Is(err, services.ErrInvalidImageFile) || errors.Is(err, services.ErrInvalidAttribute) {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{
 			"error": "Failed to update product",
@@ -569,3 +610,151 @@ func (c *AdminController) ClearCache(ctx *fiber.Ctx) error {
 		"message": "All caches cleared successfully",
 	})
 }
+
+// Helper function to convert role to response DTO
+func (c *AdminController) convertRoleToResponse(role models.Role) dto.RoleResponse {
+	permissions := make([]string, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = permission.Name
+	}
+
+	return dto.RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
+
+// @Summary Get roles
+// @Description Get all roles with their granted permissions
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} dto.RoleResponse "Success"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /admin/api/roles [get]
+func (c *AdminController) GetRoles(ctx *fiber.Ctx) error {
+	roles, err := c.roleService.GetRoles()
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch roles",
+		})
+	}
+
+	responses := make([]dto.RoleResponse, len(roles))
+	for i, role := range roles {
+		responses[i] = c.convertRoleToResponse(role)
+	}
+
+	return ctx.JSON(responses)
+}
+
+// @Summary Get permissions
+// @Description Get every permission that can be granted to a role
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} dto.PermissionResponse "Success"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /admin/api/permissions [get]
+func (c *AdminController) GetPermissions(ctx *fiber.Ctx) error {
+	permissions, err := c.roleService.GetPermissions()
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch permissions",
+		})
+	}
+
+	responses := make([]dto.PermissionResponse, len(permissions))
+	for i, permission := range permissions {
+		responses[i] = dto.PermissionResponse{
+			ID:          permission.ID,
+			Name:        permission.Name,
+			Description: permission.Description,
+		}
+	}
+
+	return ctx.JSON(responses)
+}
+
+// @Summary Create role
+// @Description Create a new role with an initial set of permissions
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param role body dto.CreateRoleRequest true "Role data"
+// @Success 201 {object} dto.RoleResponse "Role created"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Router /admin/api/roles [post]
+func (c *AdminController) CreateRole(ctx *fiber.Ctx) error {
+	var createRequest dto.CreateRoleRequest
+	if err := ctx.BodyParser(&createRequest); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	createRequest.Name = strings.TrimSpace(createRequest.Name)
+	if createRequest.Name == "" {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Role name is required",
+		})
+	}
+
+	role, err := c.roleService.CreateRole(createRequest.Name, createRequest.Description, createRequest.Permissions)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.Status(201).JSON(c.convertRoleToResponse(*role))
+}
+
+// @Summary Update role permissions
+// @Description Replace the permissions granted to a role
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID" minimum(1)
+// @Param permissions body dto.UpdateRolePermissionsRequest true "Permission names"
+// @Success 200 {object} dto.RoleResponse "Role updated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/roles/{id}/permissions [put]
+func (c *AdminController) UpdateRolePermissions(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid role ID",
+		})
+	}
+
+	var updateRequest dto.UpdateRolePermissionsRequest
+	if err := ctx.BodyParser(&updateRequest); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	role, err := c.roleService.SetRolePermissions(uint(id), updateRequest.Permissions)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "Role not found",
+			})
+		}
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(c.convertRoleToResponse(*role))
+}
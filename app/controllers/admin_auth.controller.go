@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+)
+
+type AdminAuthController struct {
+	authService    *services.AuthService
+	adminSessions  *services.AdminSessionService
+	oidcService    *services.OIDCService
+	securityEvents *services.SecurityEventService
+	validate       *validator.Validate
+}
+
+func NewAdminAuthController() *AdminAuthController {
+	return &AdminAuthController{
+		authService:    services.NewAuthService(),
+		adminSessions:  services.NewAdminSessionService(),
+		oidcService:    services.NewOIDCService(),
+		securityEvents: services.NewSecurityEventService(),
+		validate:       validator.New(),
+	}
+}
+
+// @Summary Admin login page
+// @Description Serve the admin dashboard login page. Browsers that are already signed in are sent on to the dashboard.
+// @Tags admin
+// @Produce html
+// @Param next query string false "Dashboard path to return to after login"
+// @Success 200 {string} string "Login page"
+// @Success 303 "Already signed in"
+// @Router /admin/login [get]
+func (c *AdminAuthController) LoginPage(ctx *fiber.Ctx) error {
+	if _, err := c.adminSessions.Authenticate(ctx.Cookies(services.AdminSessionCookie)); err == nil {
+		return ctx.Redirect(safeAdminRedirect(ctx.Query("next")), fiber.StatusSeeOther)
+	}
+
+	return ctx.SendFile("./views/admin/login.html")
+}
+
+// @Summary Admin dashboard login
+// @Description Log in to the admin dashboard with email and password. Sets an HttpOnly admin_session cookie and a readable admin_csrf cookie. Returns 202 with an mfa_pending token when a second factor is required.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body dto.LoginRequest true "Login credentials"
+// @Success 200 {object} dto.AdminSessionResponse
+// @Success 202 {object} dto.MFAChallengeResponse "Second factor required"
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{} "No admin access"
+// @Failure 423 {object} map[string]interface{} "Account temporarily locked"
+// @Failure 429 {object} map[string]interface{} "Too many attempts"
+// @Router /admin/login [post]
+func (c *AdminAuthController) Login(ctx *fiber.Ctx) error {
+	var req dto.LoginRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	user, err := c.authService.Authenticate(req.Email, req.Password, ctx.IP())
+	if err != nil {
+		recordLogin(c.securityEvents, ctx, req.Email, user, err)
+		return loginErrorResponse(ctx, err)
+	}
+
+	return c.startSession(ctx, *user, false)
+}
+
+// @Summary Complete admin dashboard login
+// @Description Exchange the mfa_pending token from /admin/login and a TOTP or recovery code for an admin session cookie
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body dto.MFALoginRequest true "Two-factor login request"
+// @Success 200 {object} dto.AdminSessionResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{} "No admin access"
+// @Failure 423 {object} map[string]interface{} "Account temporarily locked"
+// @Failure 429 {object} map[string]interface{} "Too many attempts"
+// @Router /admin/login/mfa [post]
+func (c *AdminAuthController) LoginMFA(ctx *fiber.Ctx) error {
+	var req dto.MFALoginRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	user, err := c.authService.VerifyMFAChallenge(req.MFAToken, req.Code, ctx.IP())
+	if err != nil {
+		recordLogin(c.securityEvents, ctx, "", user, err)
+		return mfaLoginErrorResponse(ctx, err)
+	}
+
+	return c.startSession(ctx, *user, true)
+}
+
+// @Summary Start admin dashboard single sign-on
+// @Description Redirect to the OpenID Connect provider. The provider's callback then signs the user in to the dashboard with a session cookie and returns to next.
+// @Tags admin
+// @Param provider path string true "Provider name"
+// @Param next query string false "Dashboard path to return to after login"
+// @Success 302 "Redirect to the provider"
+// @Success 303 "Back to the login page with an error"
+// @Router /admin/login/oidc/{provider} [get]
+func (c *AdminAuthController) OIDCLogin(ctx *fiber.Ctx) error {
+	provider := ctx.Params("provider")
+	next := safeAdminRedirect(ctx.Query("next"))
+
+	authURL, stateHash, err := c.oidcService.StartLogin(provider, next)
+	if err != nil {
+		message := "Identity provider is unavailable"
+		if errors.Is(err, services.ErrUnknownOIDCProvider) {
+			message = err.Error()
+		}
+		return redirectToAdminLogin(ctx, next, message)
+	}
+
+	setOIDCStateCookie(ctx, provider, stateHash, time.Now().Add(config.AppConfig.OIDCStateTTL))
+
+	return ctx.Redirect(authURL, fiber.StatusFound)
+}
+
+// completeSSO finishes a single sign-on started from the admin login page. A pending second
+// factor is handed to the login page in the URL fragment, which is not sent to the server.
+func (c *AdminAuthController) completeSSO(ctx *fiber.Ctx, login *services.OIDCLogin, err error) error {
+	var challenge *services.MFAChallenge
+	if errors.As(err, &challenge) {
+		loginURL := "/admin/login?next=" + url.QueryEscape(login.ReturnTo)
+		return sendSSORedirect(ctx, loginURL+"#mfa_token="+url.QueryEscape(challenge.Token))
+	}
+	if err != nil {
+		recordLogin(c.securityEvents, ctx, "", login.User, err)
+		return redirectToAdminLogin(ctx, login.ReturnTo, err.Error())
+	}
+
+	user := *login.User
+	session, err := c.adminSessions.Create(user, login.MFA, deviceFromContext(ctx))
+	if err != nil {
+		recordLogin(c.securityEvents, ctx, user.Email, &user, err)
+		message := "Failed to start session"
+		if errors.Is(err, services.ErrNoAdminAccess) {
+			message = err.Error()
+		}
+		return redirectToAdminLogin(ctx, login.ReturnTo, message)
+	}
+
+	recordLogin(c.securityEvents, ctx, user.Email, &user, nil)
+	setAdminCookies(ctx, session.Token, session.CSRFToken, time.Now().Add(config.AppConfig.AdminSessionTTL))
+
+	return sendSSORedirect(ctx, safeAdminRedirect(login.ReturnTo))
+}
+
+// sendSSORedirect answers the callback from the identity provider with a page that navigates to
+// target. A redirect would stay part of the provider's cross-site navigation, on which the
+// browser does not send the SameSite=Strict session cookie.
+func sendSSORedirect(ctx *fiber.Ctx, target string) error {
+	page, err := template.ParseFiles("./views/admin/sso.html")
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to load page",
+		})
+	}
+
+	var body bytes.Buffer
+	if err := page.Execute(&body, target); err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to load page",
+		})
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Type("html")
+	return ctx.Send(body.Bytes())
+}
+
+// redirectToAdminLogin sends the browser back to the login page with an error to show
+func redirectToAdminLogin(ctx *fiber.Ctx, next, message string) error {
+	query := url.Values{"next": {next}, "error": {message}}
+	return ctx.Redirect("/admin/login?"+query.Encode(), fiber.StatusSeeOther)
+}
+
+// @Summary Current admin session
+// @Description Get the signed-in dashboard user and the CSRF token of the session
+// @Tags admin
+// @Produce json
+// @Success 200 {object} dto.AdminSessionResponse
+// @Failure 401 {object} map[string]interface{}
+// @Router /admin/session [get]
+func (c *AdminAuthController) Session(ctx *fiber.Ctx) error {
+	session, ok := ctx.Locals("admin_session").(*services.AdminSession)
+	if !ok {
+		return ctx.Status(401).JSON(fiber.Map{
+			"error": "Not signed in with an admin session",
+		})
+	}
+
+	return ctx.JSON(dto.AdminSessionResponse{
+		User:      convertUserToResponse(session.User),
+		CSRFToken: session.CSRFToken,
+		MFA:       session.MFA,
+	})
+}
+
+// @Summary Admin dashboard logout
+// @Description End the admin session and clear its cookies. Requires the X-CSRF-Token header.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{} "Invalid CSRF token"
+// @Router /admin/logout [post]
+func (c *AdminAuthController) Logout(ctx *fiber.Ctx) error {
+	if token := ctx.Cookies(services.AdminSessionCookie); token != "" {
+		if err := c.adminSessions.Destroy(token); err == nil {
+			c.securityEvents.Record(newSecurityEvent(ctx, models.SecurityEventLogout, models.SecurityOutcomeSuccess))
+		}
+	}
+
+	setAdminCookies(ctx, "", "", time.Now().Add(-time.Hour))
+
+	return ctx.JSON(fiber.Map{
+		"message": "Logged out successfully",
+	})
+}
+
+// startSession creates the admin session for an authenticated user and sets its cookies
+func (c *AdminAuthController) startSession(ctx *fiber.Ctx, user models.User, mfa bool) error {
+	session, err := c.adminSessions.Create(user, mfa, deviceFromContext(ctx))
+	if errors.Is(err, services.ErrNoAdminAccess) {
+		recordLogin(c.securityEvents, ctx, user.Email, &user, err)
+		return ctx.Status(403).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to start session",
+		})
+	}
+
+	recordLogin(c.securityEvents, ctx, user.Email, &user, nil)
+
+	ttl := config.AppConfig.AdminSessionTTL
+	setAdminCookies(ctx, session.Token, session.CSRFToken, time.Now().Add(ttl))
+
+	return ctx.JSON(dto.AdminSessionResponse{
+		User:      convertUserToResponse(user),
+		CSRFToken: session.CSRFToken,
+		MFA:       mfa,
+		ExpiresIn: int64(ttl.Seconds()),
+	})
+}
+
+// safeAdminRedirect only allows redirects back into the admin dashboard
+func safeAdminRedirect(next string) string {
+	if strings.HasPrefix(next, "/admin") && !strings.HasPrefix(next, "/admin/login") && !strings.ContainsAny(next, "\\\r\n") {
+		return next
+	}
+	return "/admin"
+}
+
+// setAdminCookies sets the session and CSRF cookies, or clears them when expires is in the past.
+// Both are scoped to /admin and SameSite=Strict; only the CSRF cookie is readable by scripts.
+func setAdminCookies(ctx *fiber.Ctx, sessionToken, csrfToken string, expires time.Time) {
+	ctx.Cookie(&fiber.Cookie{
+		Name:     services.AdminSessionCookie,
+		Value:    sessionToken,
+		Path:     "/admin",
+		Expires:  expires,
+		Secure:   config.AppConfig.AdminCookieSecure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteStrictMode,
+	})
+	ctx.Cookie(&fiber.Cookie{
+		Name:     services.AdminCSRFCookie,
+		Value:    csrfToken,
+		Path:     "/admin",
+		Expires:  expires,
+		Secure:   config.AppConfig.AdminCookieSecure,
+		SameSite: fiber.CookieSameSiteStrictMode,
+	})
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import "testing"
+
+func TestSafeAdminRedirect(t *testing.T) {
+	tests := []struct {
+		next string
+		want string
+	}{
+		{"/admin", "/admin"},
+		{"/admin/products?page=2", "/admin/products?page=2"},
+		{"/admin/users#roles", "/admin/users#roles"},
+		{"", "/admin"},
+		{"/", "/admin"},
+		{"/api/auth/me", "/admin"},
+		{"https://evil.example/admin", "/admin"},
+		{"//evil.example/admin", "/admin"},
+		{"/admin/login", "/admin"},
+		{"/admin/login?next=/admin", "/admin"},
+		{"/admin/login/oidc/corp", "/admin"},
+		{"/admin\\@evil.example", "/admin"},
+		{"/admin/\r\nSet-Cookie: x=1", "/admin"},
+	}
+	for _, tt := range tests {
+		if got := safeAdminRedirect(tt.next); got != tt.want {
+			t.Errorf("safeAdminRedirect(%q) = %q, want %q", tt.next, got, tt.want)
+		}
+	}
+}
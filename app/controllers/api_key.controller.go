@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type APIKeyController struct {
+	apiKeyService  *services.APIKeyService
+	securityEvents *services.SecurityEventService
+	validate       *validator.Validate
+}
+
+func NewAPIKeyController() *APIKeyController {
+	return &APIKeyController{
+		apiKeyService:  services.NewAPIKeyService(),
+		securityEvents: services.NewSecurityEventService(),
+		validate:       validator.New(),
+	}
+}
+
+// @Summary Create service account
+// @Description Create a service account for a machine client. Service accounts cannot log in with a password and authenticate with API keys only.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param account body dto.CreateServiceAccountRequest true "Service account data"
+// @Success 201 {object} dto.ServiceAccountResponse "Service account created"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Router /admin/api/service-accounts [post]
+func (c *APIKeyController) CreateServiceAccount(ctx *fiber.Ctx) error {
+	var req dto.CreateServiceAccountRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	user, err := c.apiKeyService.CreateServiceAccount(req.Name, req.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownRole) {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to create service account",
+		})
+	}
+
+	return ctx.Status(201).JSON(convertServiceAccountToResponse(*user))
+}
+
+// @Summary List service accounts
+// @Description Get all service accounts
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} dto.ServiceAccountResponse "List of service accounts"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Router /admin/api/service-accounts [get]
+func (c *APIKeyController) GetServiceAccounts(ctx *fiber.Ctx) error {
+	users, err := c.apiKeyService.GetServiceAccounts()
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch service accounts",
+		})
+	}
+
+	response := make([]dto.ServiceAccountResponse, len(users))
+	for i, user := range users {
+		response[i] = convertServiceAccountToResponse(user)
+	}
+
+	return ctx.JSON(response)
+}
+
+// @Summary Create API key
+// @Description Issue an API key to a service account. Scopes must be permissions granted to the account's role. The key is only shown in this response.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Service account ID" minimum(1)
+// @Param key body dto.CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} dto.APIKeyResponse "API key created"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/service-accounts/{id}/api-keys [post]
+func (c *APIKeyController) CreateAPIKey(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid service account ID",
+		})
+	}
+
+	var req dto.CreateAPIKeyRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "expires_at must be in the future",
+		})
+	}
+
+	createdBy, _ := ctx.Locals("user_id").(uint)
+	key, rawKey, err := c.apiKeyService.CreateKey(uint(id), req.Name, req.Scopes, req.ExpiresAt, createdBy)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "Service account not found",
+			})
+		}
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := convertAPIKeyToResponse(*key)
+	response.Key = rawKey
+
+	return ctx.Status(201).JSON(response)
+}
+
+// @Summary List API keys
+// @Description Get all API keys, optionally filtered by service account. Keys themselves are never returned.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param service_account_id query int false "Service account ID"
+// @Success 200 {array} dto.APIKeyResponse "List of API keys"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Router /admin/api/api-keys [get]
+func (c *APIKeyController) GetAPIKeys(ctx *fiber.Ctx) error {
+	var serviceAccountID *uint
+	if value := ctx.Query("service_account_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": "Invalid service account ID",
+			})
+		}
+		accountID := uint(id)
+		serviceAccountID = &accountID
+	}
+
+	keys, err := c.apiKeyService.GetKeys(serviceAccountID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch API keys",
+		})
+	}
+
+	response := make([]dto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		response[i] = convertAPIKeyToResponse(key)
+	}
+
+	return ctx.JSON(response)
+}
+
+// @Summary Revoke API key
+// @Description Revoke an API key. Requests made with it are rejected immediately.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "API key ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "API key revoked"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/api-keys/{id} [delete]
+func (c *APIKeyController) RevokeAPIKey(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid API key ID",
+		})
+	}
+
+	key, err := c.apiKeyService.RevokeKey(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "API key not found",
+			})
+		}
+		if errors.Is(err, services.ErrAPIKeyAlreadyRevoked) {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to revoke API key",
+		})
+	}
+
+	event := newAdminSecurityEvent(ctx, models.SecurityEventAPIKeyRevoked, key.UserID)
+	event.Detail = "key " + key.Prefix
+	c.securityEvents.Record(event)
+
+	return ctx.JSON(fiber.Map{
+		"message": "API key revoked successfully",
+	})
+}
+
+func convertServiceAccountToResponse(user models.User) dto.ServiceAccountResponse {
+	return dto.ServiceAccountResponse{
+		ID:        user.ID,
+		Name:      user.FirstName,
+		Email:     user.Email,
+		Role:      user.Role,
+		Active:    user.Active,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+func convertAPIKeyToResponse(key models.APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:               key.ID,
+		ServiceAccountID: key.UserID,
+		Name:             key.Name,
+		Prefix:           key.Prefix,
+		Scopes:           key.Scopes,
+		ExpiresAt:        key.ExpiresAt,
+		LastUsedAt:       key.LastUsedAt,
+		RevokedAt:        key.RevokedAt,
+		CreatedAt:        key.CreatedAt,
+	}
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type AttributeController struct {
+	attributeService *services.AttributeService
+	validate         *validator.Validate
+}
+
+func NewAttributeController() *AttributeController {
+	return &AttributeController{
+		attributeService: services.NewAttributeService(),
+		validate:         validator.New(),
+	}
+}
+
+// @Summary Get category attributes
+// @Description Get the attributes products of a category can have, in display order. Use their keys to filter product search with attr.<key>.
+// @Tags categories
+// @Produce json
+// @Param id path int true "Category ID" minimum(1)
+// @Success 200 {array} dto.AttributeDefinitionResponse "Success"
+// @Failure 400 {object} map[string]interface{} "Bad Request - Invalid category ID"
+// @Failure 404 {object} map[string]interface{} "Not Found - Category not found"
+// @Router /api/categories/{id}/attributes [get]
+func (c *AttributeController) GetAttributes(ctx *fiber.Ctx) error {
+	categoryID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	definitions, err := c.attributeService.GetDefinitions(uint(categoryID))
+	if err != nil {
+		return attributeErrorResponse(ctx, err, "Failed to fetch attributes")
+	}
+
+	responses := make([]dto.AttributeDefinitionResponse, len(definitions))
+	for i, definition := range definitions {
+		responses[i] = convertAttributeDefinitionToResponse(definition)
+	}
+
+	return ctx.JSON(responses)
+}
+
+// @Summary Create category attribute
+// @Description Add an attribute to a category, such as "RAM" as an integer in GB or "Material" from a list of options. The key is derived from the name when it is not given.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID" minimum(1)
+// @Param attribute body dto.CreateAttributeDefinitionRequest true "Attribute definition"
+// @Success 201 {object} dto.AttributeDefinitionResponse "Attribute created"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "Key already used in the category"
+// @Router /admin/api/categories/{id}/attributes [post]
+func (c *AttributeController) CreateAttribute(ctx *fiber.Ctx) error {
+	categoryID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	var req dto.CreateAttributeDefinitionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	definition, err := c.attributeService.CreateDefinition(uint(categoryID), req)
+	if err != nil {
+		return attributeErrorResponse(ctx, err, "Failed to create attribute")
+	}
+
+	return ctx.Status(201).JSON(convertAttributeDefinitionToResponse(*definition))
+}
+
+// @Summary Update category attribute
+// @Description Change the name, unit, options, required flag or position of an attribute. Its key and type cannot change.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID" minimum(1)
+// @Param attributeId path int true "Attribute ID" minimum(1)
+// @Param attribute body dto.UpdateAttributeDefinitionRequest true "Attribute changes"
+// @Success 200 {object} dto.AttributeDefinitionResponse "Attribute updated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Attribute not found"
+// @Router /admin/api/categories/{id}/attributes/{attributeId} [put]
+func (c *AttributeController) UpdateAttribute(ctx *fiber.Ctx) error {
+	categoryID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	attributeID, err := strconv.ParseUint(ctx.Params("attributeId"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid attribute ID",
+		})
+	}
+
+	var req dto.UpdateAttributeDefinitionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	definition, err := c.attributeService.UpdateDefinition(uint(categoryID), uint(attributeID), req)
+	if err != nil {
+		return attributeErrorResponse(ctx, err, "Failed to update attribute")
+	}
+
+	return ctx.JSON(convertAttributeDefinitionToResponse(*definition))
+}
+
+// @Summary Delete category attribute
+// @Description Remove an attribute from a category together with its value on every product
+// @Tags admin
+// @Produce json
+// @Param id path int true "Category ID" minimum(1)
+// @Param attributeId path int true "Attribute ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "Attribute deleted"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Attribute not found"
+// @Router /admin/api/categories/{id}/attributes/{attributeId} [delete]
+func (c *AttributeController) DeleteAttribute(ctx *fiber.Ctx) error {
+	categoryID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	attributeID, err := strconv.ParseUint(ctx.Params("attributeId"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid attribute ID",
+		})
+	}
+
+	if err := c.attributeService.DeleteDefinition(uint(categoryID), uint(attributeID)); err != nil {
+		return attributeErrorResponse(ctx, err, "Failed to delete attribute")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Attribute deleted successfully",
+	})
+}
+
+func attributeErrorResponse(ctx *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "Category or attribute not found",
+		})
+	case errors.Is(err, services.ErrInvalidAttributeKey), errors.Is(err, services.ErrAttributeOptionsEmpty):
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrAttributeExists):
+		return ctx.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+}
+
+func convertAttributeDefinitionToResponse(definition models.AttributeDefinition) dto.AttributeDefinitionResponse {
+	return dto.AttributeDefinitionResponse{
+		ID:         definition.ID,
+		CategoryID: definition.CategoryID,
+		Key:        definition.Key,
+		Name:       definition.Name,
+		Type:       definition.Type,
+		Unit:       definition.Unit,
+		Options:    definition.Options,
+		Required:   definition.Required,
+		Position:   definition.Position,
+	}
+}
@@ -1,24 +1,45 @@
 package controllers
 
 import (
+	"errors"
+	"log"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
 	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
 )
 
 type AuthController struct {
-	authService *services.AuthService
-	validate    *validator.Validate
+	authService    *services.AuthService
+	mfaService     *services.MFAService
+	oidcService    *services.OIDCService
+	adminAuth      *AdminAuthController
+	sessionService *services.SessionService
+	privacyService *services.PrivacyService
+	securityEvents *services.SecurityEventService
+	impersonations *services.ImpersonationService
+	validate       *validator.Validate
 }
 
 func NewAuthController() *AuthController {
 	return &AuthController{
-		authService: services.NewAuthService(),
-		validate:    validator.New(),
+		authService:    services.NewAuthService(),
+		mfaService:     services.NewMFAService(),
+		oidcService:    services.NewOIDCService(),
+		adminAuth:      NewAdminAuthController(),
+		sessionService: services.NewSessionService(),
+		privacyService: services.NewPrivacyService(),
+		securityEvents: services.NewSecurityEventService(),
+		impersonations: services.NewImpersonationService(),
+		validate:       validator.New(),
 	}
 }
 
@@ -34,6 +55,10 @@ func formatValidationError(err error) string {
 				errors = append(errors, e.Field()+" must be a valid email address")
 			case "min":
 				errors = append(errors, e.Field()+" must be at least "+e.Param()+" characters")
+			case "max":
+				errors = append(errors, e.Field()+" must be at most "+e.Param()+" characters")
+			case "len":
+				errors = append(errors, e.Field()+" must be exactly "+e.Param()+" characters")
 			default:
 				errors = append(errors, e.Field()+" failed validation: "+e.Tag())
 			}
@@ -43,8 +68,77 @@ func formatValidationError(err error) string {
 	return err.Error()
 }
 
+// deviceFromContext describes the client making the request, for session tracking
+func deviceFromContext(ctx *fiber.Ctx) services.Device {
+	return services.Device{
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+		IP:        ctx.IP(),
+	}
+}
+
+// newSecurityEvent describes an event for the request, on the authenticated user's account
+// when there is one. Events during impersonation name the admin as the actor.
+func newSecurityEvent(ctx *fiber.Ctx, eventType, outcome string) models.SecurityEvent {
+	event := models.SecurityEvent{
+		Type:      eventType,
+		Outcome:   outcome,
+		IP:        ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
+	if userID, ok := ctx.Locals("user_id").(uint); ok {
+		event.UserID = &userID
+	}
+	if impersonatorID, ok := ctx.Locals("impersonator_id").(uint); ok {
+		event.ActorID = &impersonatorID
+	}
+	return event
+}
+
+// convertSessionsToResponse maps sessions to response DTOs, flagging the one the request was made with
+func convertSessionsToResponse(sessions []services.Session, currentSessionID string) []dto.SessionResponse {
+	response := make([]dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = dto.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			MFA:        session.MFA,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    currentSessionID != "" && session.ID == currentSessionID,
+		}
+		if session.ImpersonatorID != 0 {
+			response[i].ImpersonatedBy = &session.ImpersonatorID
+		}
+	}
+	return response
+}
+
+// convertUserToResponse maps a user model to the public response DTO
+func convertUserToResponse(user models.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		MFAEnabled:    user.MFAEnabled,
+	}
+}
+
+// setRetryAfter sets the Retry-After header and returns the value in whole seconds
+func setRetryAfter(ctx *fiber.Ctx, wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return seconds
+}
+
 // @Summary Register a new user
-// @Description Register a new user account
+// @Description Register a new user account and send a verification email. When email verification is required no tokens are issued until the address is confirmed.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -68,12 +162,29 @@ func (c *AuthController) Register(ctx *fiber.Ctx) error {
 
 	user, err := c.authService.Register(req.Email, req.Password, req.FirstName, req.LastName)
 	if err != nil {
+		event := newSecurityEvent(ctx, models.SecurityEventRegister, models.SecurityOutcomeFailure)
+		event.Email = req.Email
+		event.Detail = err.Error()
+		c.securityEvents.Record(event)
+
 		return ctx.Status(400).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	token, err := c.authService.GenerateJWT(*user)
+	event := newSecurityEvent(ctx, models.SecurityEventRegister, models.SecurityOutcomeSuccess)
+	event.UserID = &user.ID
+	event.Email = user.Email
+	c.securityEvents.Record(event)
+
+	if config.AppConfig.RequireEmailVerification {
+		return ctx.Status(201).JSON(dto.AuthResponse{
+			User:    convertUserToResponse(*user),
+			Message: "Please verify your email address before logging in",
+		})
+	}
+
+	tokens, err := c.authService.IssueTokens(*user, false, deviceFromContext(ctx))
 	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{
 			"error": "Failed to generate token",
@@ -81,27 +192,27 @@ func (c *AuthController) Register(ctx *fiber.Ctx) error {
 	}
 
 	response := dto.AuthResponse{
-		Token: token,
-		User: dto.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-			Role:      user.Role,
-		},
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         convertUserToResponse(*user),
 	}
 
 	return ctx.Status(201).JSON(response)
 }
 
 // @Summary Login user
-// @Description Login with email and password
+// @Description Login with email and password. Accounts with two-factor authentication receive an mfa_pending token to exchange at /api/auth/login/mfa.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param body body dto.LoginRequest true "Login request"
 // @Success 200 {object} dto.AuthResponse
+// @Success 202 {object} dto.MFAChallengeResponse "Second factor required"
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{} "Email not verified"
+// @Failure 423 {object} map[string]interface{} "Account temporarily locked"
+// @Failure 429 {object} map[string]interface{} "Too many attempts"
 // @Router /api/auth/login [post]
 func (c *AuthController) Login(ctx *fiber.Ctx) error {
 	var req dto.LoginRequest
@@ -117,56 +228,195 @@ func (c *AuthController) Login(ctx *fiber.Ctx) error {
 		})
 	}
 
-	user, token, err := c.authService.Login(req.Email, req.Password)
+	user, tokens, err := c.authService.Login(req.Email, req.Password, deviceFromContext(ctx))
+	recordLogin(c.securityEvents, ctx, req.Email, user, err)
+
 	if err != nil {
+		return loginErrorResponse(ctx, err)
+	}
+
+	response := dto.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         convertUserToResponse(*user),
+	}
+
+	return ctx.JSON(response)
+}
+
+// @Summary Complete two-factor login
+// @Description Exchange the mfa_pending token from /api/auth/login and a TOTP or recovery code for access and refresh tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.MFALoginRequest true "Two-factor login request"
+// @Success 200 {object} dto.AuthResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 423 {object} map[string]interface{} "Account temporarily locked"
+// @Failure 429 {object} map[string]interface{} "Too many attempts"
+// @Router /api/auth/login/mfa [post]
+func (c *AuthController) LoginMFA(ctx *fiber.Ctx) error {
+	var req dto.MFALoginRequest
+	if err := ctx.BodyParser(&req); err != nil {
 		return ctx.Status(400).JSON(fiber.Map{
-			"error": err.Error(),
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
 		})
 	}
 
+	user, tokens, err := c.authService.CompleteMFALogin(req.MFAToken, req.Code, deviceFromContext(ctx))
+	recordLogin(c.securityEvents, ctx, "", user, err)
+	if err != nil {
+		return mfaLoginErrorResponse(ctx, err)
+	}
+
 	response := dto.AuthResponse{
-		Token: token,
-		User: dto.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-			Role:      user.Role,
-		},
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         convertUserToResponse(*user),
 	}
 
 	return ctx.JSON(response)
 }
 
-// @Summary Logout user
-// @Description Logout and invalidate session
+// loginErrorResponse maps a failed password login, including a pending second factor, to an HTTP response
+func loginErrorResponse(ctx *fiber.Ctx, err error) error {
+	var locked *services.AccountLockedError
+	var throttled *services.ThrottledError
+	var challenge *services.MFAChallenge
+	switch {
+	case errors.As(err, &locked):
+		retryAfter := setRetryAfter(ctx, locked.RetryAfter)
+		return ctx.Status(fiber.StatusLocked).JSON(fiber.Map{
+			"error":       err.Error(),
+			"retry_after": retryAfter,
+		})
+	case errors.As(err, &throttled):
+		retryAfter := setRetryAfter(ctx, throttled.RetryAfter)
+		return ctx.Status(429).JSON(fiber.Map{
+			"error":       "Too many login attempts",
+			"retry_after": retryAfter,
+		})
+	case errors.As(err, &challenge):
+		return ctx.Status(202).JSON(dto.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    challenge.Token,
+			ExpiresIn:   challenge.ExpiresIn,
+		})
+	case errors.Is(err, services.ErrEmailNotVerified):
+		return ctx.Status(403).JSON(fiber.Map{
+			"error":          err.Error(),
+			"email_verified": false,
+		})
+	default:
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+}
+
+// mfaLoginErrorResponse maps a failed second factor login to an HTTP response
+func mfaLoginErrorResponse(ctx *fiber.Ctx, err error) error {
+	var locked *services.AccountLockedError
+	var throttled *services.ThrottledError
+	switch {
+	case errors.Is(err, services.ErrInvalidMFAToken), errors.Is(err, services.ErrInvalidMFACode):
+		return ctx.Status(401).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.As(err, &locked), errors.As(err, &throttled):
+		return loginErrorResponse(ctx, err)
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to complete login",
+		})
+	}
+}
+
+// recordLogin records the outcome of a login attempt. A pending second factor is not recorded;
+// the login is recorded once the challenge has been answered.
+func recordLogin(events *services.SecurityEventService, ctx *fiber.Ctx, email string, user *models.User, err error) {
+	var challenge *services.MFAChallenge
+	if errors.As(err, &challenge) {
+		return
+	}
+
+	event := newSecurityEvent(ctx, models.SecurityEventLogin, models.SecurityOutcomeSuccess)
+	event.Email = email
+	if user != nil {
+		event.UserID = &user.ID
+		event.Email = user.Email
+	}
+	if err != nil {
+		event.Outcome = models.SecurityOutcomeFailure
+		event.Detail = err.Error()
+	}
+	events.Record(event)
+}
+
+// @Summary Start two-factor enrolment
+// @Description Generate a TOTP secret and its otpauth URI. Two-factor authentication is enabled once the secret is confirmed with a code.
 // @Tags auth
 // @Security BearerAuth
-// @Success 200 {object} map[string]interface{}
-// @Router /api/auth/logout [post]
-func (c *AuthController) Logout(ctx *fiber.Ctx) error {
-	token := ctx.Get("Authorization")
-	if token != "" {
-		token = token[7:] // Remove "Bearer " prefix
-		c.authService.Logout(token)
+// @Produce json
+// @Success 200 {object} dto.MFAEnrolmentResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/auth/mfa/enroll [post]
+func (c *AuthController) EnrollMFA(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	user, err := c.authService.GetUserByID(userID)
+	if err != nil {
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "User not found",
+		})
 	}
 
-	return ctx.JSON(fiber.Map{
-		"message": "Logged out successfully",
+	enrolment, err := c.mfaService.StartEnrolment(*user)
+	if err != nil {
+		return mfaErrorResponse(ctx, err)
+	}
+
+	return ctx.JSON(dto.MFAEnrolmentResponse{
+		Secret:     enrolment.Secret,
+		OTPAuthURI: enrolment.OTPAuthURI,
 	})
 }
 
-// @Summary Get user profile
-// @Description Get current user profile
+// @Summary Confirm two-factor enrolment
+// @Description Enable two-factor authentication with a code from the authenticator app. Returns one-time recovery codes that are shown only once.
 // @Tags auth
 // @Security BearerAuth
+// @Accept json
 // @Produce json
-// @Success 200 {object} dto.UserResponse
-// @Failure 401 {object} map[string]interface{}
-// @Router /api/auth/profile [get]
-func (c *AuthController) GetProfile(ctx *fiber.Ctx) error {
+// @Param body body dto.MFACodeRequest true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/auth/mfa/confirm [post]
+func (c *AuthController) ConfirmMFA(ctx *fiber.Ctx) error {
 	userID := ctx.Locals("user_id").(uint)
 
+	var req dto.MFACodeRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
 	user, err := c.authService.GetUserByID(userID)
 	if err != nil {
 		return ctx.Status(404).JSON(fiber.Map{
@@ -174,41 +424,86 @@ func (c *AuthController) GetProfile(ctx *fiber.Ctx) error {
 		})
 	}
 
-	response := dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
+	codes, err := c.mfaService.ConfirmEnrolment(*user, req.Code)
+	if err != nil {
+		return mfaErrorResponse(ctx, err)
 	}
 
-	return ctx.JSON(response)
+	c.securityEvents.Record(newSecurityEvent(ctx, models.SecurityEventMFAEnabled, models.SecurityOutcomeSuccess))
+
+	return ctx.JSON(dto.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
 }
 
-// @Summary Update user profile
-// @Description Update current user profile
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication after checking a TOTP or recovery code. Not allowed for roles that require it.
 // @Tags auth
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param body body dto.UpdateProfileRequest true "Update profile request"
-// @Success 200 {object} dto.UserResponse
+// @Param body body dto.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
-// @Router /api/auth/profile [put]
-func (c *AuthController) UpdateProfile(ctx *fiber.Ctx) error {
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/auth/mfa/disable [post]
+func (c *AuthController) DisableMFA(ctx *fiber.Ctx) error {
 	userID := ctx.Locals("user_id").(uint)
 
-	var req dto.UpdateProfileRequest
+	var req dto.MFACodeRequest
 	if err := ctx.BodyParser(&req); err != nil {
 		return ctx.Status(400).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
 	}
 
-	err := c.authService.UpdateProfile(userID, req.FirstName, req.LastName, req.Phone, req.Address, req.City, req.Country, req.PostalCode)
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	user, err := c.authService.GetUserByID(userID)
 	if err != nil {
-		return ctx.Status(500).JSON(fiber.Map{
-			"error": "Failed to update profile",
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	if err := c.mfaService.Disable(*user, req.Code); err != nil {
+		return mfaErrorResponse(ctx, err)
+	}
+
+	c.securityEvents.Record(newSecurityEvent(ctx, models.SecurityEventMFADisabled, models.SecurityOutcomeSuccess))
+
+	return ctx.JSON(fiber.Map{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after checking a TOTP or recovery code
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body dto.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/auth/mfa/recovery-codes [post]
+func (c *AuthController) RegenerateRecoveryCodes(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req dto.MFACodeRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
 		})
 	}
 
@@ -219,13 +514,773 @@ func (c *AuthController) UpdateProfile(ctx *fiber.Ctx) error {
 		})
 	}
 
-	response := dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
+	codes, err := c.mfaService.RegenerateRecoveryCodes(*user, req.Code)
+	if err != nil {
+		return mfaErrorResponse(ctx, err)
 	}
 
-	return ctx.JSON(response)
+	return ctx.JSON(dto.RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+}
+
+// mfaErrorResponse maps two-factor service errors to HTTP responses
+func mfaErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrMFARequiredByRole):
+		return ctx.Status(403).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrMFAAlreadyEnabled),
+		errors.Is(err, services.ErrMFANotEnabled),
+		errors.Is(err, services.ErrMFANoEnrolment),
+		errors.Is(err, services.ErrInvalidMFACode):
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Two-factor authentication request failed",
+		})
+	}
+}
+
+// @Summary List single sign-on providers
+// @Description Names of the configured OpenID Connect providers, for rendering SSO buttons
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.OIDCProvidersResponse
+// @Router /api/auth/oidc/providers [get]
+func (c *AuthController) OIDCProviders(ctx *fiber.Ctx) error {
+	names := make([]string, len(config.AppConfig.OIDCProviders))
+	for i, provider := range config.AppConfig.OIDCProviders {
+		names[i] = provider.Name
+	}
+
+	return ctx.JSON(dto.OIDCProvidersResponse{
+		Providers: names,
+	})
+}
+
+// @Summary Start single sign-on
+// @Description Redirect to the OpenID Connect provider using the authorization code flow with PKCE
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /api/auth/oidc/{provider}/login [get]
+func (c *AuthController) OIDCLogin(ctx *fiber.Ctx) error {
+	provider := ctx.Params("provider")
+	authURL, stateHash, err := c.oidcService.StartLogin(provider, "")
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownOIDCProvider) {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(502).JSON(fiber.Map{
+			"error": "Identity provider is unavailable",
+		})
+	}
+
+	setOIDCStateCookie(ctx, provider, stateHash, time.Now().Add(config.AppConfig.OIDCStateTTL))
+
+	return ctx.Redirect(authURL, fiber.StatusFound)
+}
+
+// @Summary Complete single sign-on
+// @Description Callback from the OpenID Connect provider. Only the browser that started the login, holding its oidc_state cookie, can complete it. Links or provisions the user by verified email, maps IdP groups to a role and returns tokens. Returns 202 when local two-factor authentication is still required. Logins started from the admin login page instead get a dashboard session and a page that continues to the dashboard.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the login request"
+// @Success 200 {object} dto.AuthResponse
+// @Success 202 {object} dto.MFAChallengeResponse
+// @Success 303 "Dashboard logins: back to the login page with an error"
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/auth/oidc/{provider}/callback [get]
+func (c *AuthController) OIDCCallback(ctx *fiber.Ctx) error {
+	if idpError := ctx.Query("error"); idpError != "" {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error":             "Identity provider returned an error: " + idpError,
+			"error_description": ctx.Query("error_description"),
+		})
+	}
+
+	code, state := ctx.Query("code"), ctx.Query("state")
+	if code == "" || state == "" {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "code and state are required",
+		})
+	}
+
+	provider := ctx.Params("provider")
+	login, err := c.oidcService.CompleteLogin(provider, code, state, ctx.Cookies(services.OIDCStateCookie))
+	setOIDCStateCookie(ctx, provider, "", time.Unix(0, 0))
+
+	// Logins started from the admin login page end in a dashboard session
+	if login != nil && login.ReturnTo != "" {
+		return c.adminAuth.completeSSO(ctx, login, err)
+	}
+
+	var user *models.User
+	var tokens *services.TokenPair
+	if login != nil {
+		user = login.User
+	}
+	if err == nil {
+		tokens, err = c.authService.IssueTokens(*login.User, login.MFA, deviceFromContext(ctx))
+	}
+	recordLogin(c.securityEvents, ctx, "", user, err)
+
+	var challenge *services.MFAChallenge
+	switch {
+	case errors.As(err, &challenge):
+		return ctx.Status(202).JSON(dto.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    challenge.Token,
+			ExpiresIn:   challenge.ExpiresIn,
+		})
+	case errors.Is(err, services.ErrUnknownOIDCProvider):
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrOIDCNoAccount), errors.Is(err, services.ErrOIDCAccountDisabled),
+		errors.Is(err, services.ErrOIDCLinkNotAllowed):
+		return ctx.Status(403).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(dto.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         convertUserToResponse(*user),
+	})
+}
+
+// setOIDCStateCookie sets the cookie that ties a single sign-on login to the browser, or clears it
+// when expires is in the past. SameSite=Lax lets it through on the redirect back from the provider.
+func setOIDCStateCookie(ctx *fiber.Ctx, provider, stateHash string, expires time.Time) {
+	secure := true
+	if cfg, ok := config.AppConfig.OIDCProvider(provider); ok {
+		secure = strings.HasPrefix(cfg.RedirectURL, "https://")
+	}
+
+	ctx.Cookie(&fiber.Cookie{
+		Name:     services.OIDCStateCookie,
+		Value:    stateHash,
+		Path:     "/api/auth/oidc",
+		Expires:  expires,
+		Secure:   secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token. Reusing a rotated refresh token revokes the whole token family.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.RefreshTokenRequest true "Refresh token request"
+// @Success 200 {object} dto.AuthResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/auth/refresh [post]
+func (c *AuthController) Refresh(ctx *fiber.Ctx) error {
+	var req dto.RefreshTokenRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	user, tokens, err := c.authService.Refresh(req.RefreshToken, deviceFromContext(ctx))
+	var reused *services.RefreshTokenReusedError
+	if errors.As(err, &reused) {
+		// A replayed refresh token usually means it was stolen; the whole session has been revoked
+		event := newSecurityEvent(ctx, models.SecurityEventRefreshTokenReuse, models.SecurityOutcomeFailure)
+		event.UserID = &reused.UserID
+		event.Detail = "session revoked"
+		c.securityEvents.Record(event)
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			return ctx.Status(401).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to refresh token",
+		})
+	}
+
+	response := dto.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         convertUserToResponse(*user),
+	}
+
+	return ctx.JSON(response)
+}
+
+// @Summary Logout user
+// @Description Logout and end the current session, including its refresh token.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Param body body dto.LogoutRequest false "Logout request"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/auth/logout [post]
+func (c *AuthController) Logout(ctx *fiber.Ctx) error {
+	token := ctx.Get("Authorization")
+	if token != "" {
+		token = token[7:] // Remove "Bearer " prefix
+		c.authService.Logout(token)
+	}
+
+	// Ending the session also revokes the refresh token issued alongside the access token
+	if sessionID, ok := ctx.Locals("session_id").(string); ok {
+		c.sessionService.RevokeSession(ctx.Locals("user_id").(uint), sessionID)
+
+		if _, impersonating := ctx.Locals("impersonator_id").(uint); impersonating {
+			if _, err := c.impersonations.EndBySessionID(sessionID); err == nil {
+				c.securityEvents.Record(newSecurityEvent(ctx, models.SecurityEventImpersonationEnd, models.SecurityOutcomeSuccess))
+				return ctx.JSON(fiber.Map{
+					"message": "Impersonation ended",
+				})
+			}
+		}
+	}
+
+	var req dto.LogoutRequest
+	if err := ctx.BodyParser(&req); err == nil && req.RefreshToken != "" {
+		c.authService.RevokeRefreshToken(req.RefreshToken)
+	}
+
+	c.securityEvents.Record(newSecurityEvent(ctx, models.SecurityEventLogout, models.SecurityOutcomeSuccess))
+
+	return ctx.JSON(fiber.Map{
+		"message": "Logged out successfully",
+	})
+}
+
+// @Summary Logout from all devices
+// @Description Revoke every session and refresh token of the current user
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/auth/logout-all [post]
+func (c *AuthController) LogoutAll(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	if err := c.authService.RevokeAllSessions(userID); err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to revoke sessions",
+		})
+	}
+
+	c.securityEvents.Record(newSecurityEvent(ctx, models.SecurityEventLogoutAll, models.SecurityOutcomeSuccess))
+
+	return ctx.JSON(fiber.Map{
+		"message": "Logged out from all sessions successfully",
+	})
+}
+
+// @Summary Change password
+// @Description Change the password of the current user. The current password is required, the new one must meet the password policy, and every other session is ended.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dto.AuthResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/auth/password [put]
+func (c *AuthController) ChangePassword(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	mfa, _ := ctx.Locals("user_mfa").(bool)
+
+	var req dto.ChangePasswordRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	user, err := c.authService.ChangePassword(userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		event := newSecurityEvent(ctx, models.SecurityEventPasswordChange, models.SecurityOutcomeFailure)
+		event.Detail = err.Error()
+		c.securityEvents.Record(event)
+
+		var policyErr *services.PasswordPolicyError
+		switch {
+		case errors.Is(err, services.ErrInvalidPassword):
+			return ctx.Status(401).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.As(err, &policyErr):
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return ctx.Status(500).JSON(fiber.Map{
+				"error": "Failed to change password",
+			})
+		}
+	}
+
+	c.securityEvents.Record(newSecurityEvent(ctx, models.SecurityEventPasswordChange, models.SecurityOutcomeSuccess))
+
+	// All sessions were revoked, including the current one, so issue a fresh pair
+	tokens, err := c.authService.IssueTokens(*user, mfa, deviceFromContext(ctx))
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	return ctx.JSON(dto.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         convertUserToResponse(*user),
+		Message:      "Password changed successfully",
+	})
+}
+
+// @Summary List sessions
+// @Description List the devices the current user is logged in on
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} dto.SessionResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/auth/sessions [get]
+func (c *AuthController) GetSessions(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+	currentSessionID, _ := ctx.Locals("session_id").(string)
+
+	sessions, err := c.sessionService.GetSessions(userID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch sessions",
+		})
+	}
+
+	return ctx.JSON(convertSessionsToResponse(sessions, currentSessionID))
+}
+
+// @Summary Revoke session
+// @Description Log out one of the current user's sessions. Its access and refresh tokens stop working immediately.
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/auth/sessions/{id} [delete]
+func (c *AuthController) RevokeSession(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	if err := c.sessionService.RevokeSession(userID, ctx.Params("id")); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to revoke session",
+		})
+	}
+
+	event := newSecurityEvent(ctx, models.SecurityEventSessionRevoked, models.SecurityOutcomeSuccess)
+	event.Detail = "session " + ctx.Params("id")
+	c.securityEvents.Record(event)
+
+	return ctx.JSON(fiber.Map{
+		"message": "Session revoked successfully",
+	})
+}
+
+// @Summary Verify email address
+// @Description Confirm an email address using the signed link sent after registration
+// @Tags auth
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/auth/verify-email [get]
+func (c *AuthController) VerifyEmail(ctx *fiber.Ctx) error {
+	token := ctx.Query("token")
+	if token == "" {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "token is required",
+		})
+	}
+
+	user, err := c.authService.VerifyEmail(token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerifyToken) {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to verify email",
+		})
+	}
+
+	return ctx.JSON(convertUserToResponse(*user))
+}
+
+// @Summary Resend verification email
+// @Description Send a new email verification link. Requests are throttled per email address.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.ResendVerificationRequest true "Resend verification request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
+// @Router /api/auth/resend-verification [post]
+func (c *AuthController) ResendVerification(ctx *fiber.Ctx) error {
+	var req dto.ResendVerificationRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	if err := c.authService.ResendVerificationEmail(req.Email); err != nil {
+		var throttled *services.ThrottledError
+		if errors.As(err, &throttled) {
+			retryAfter := setRetryAfter(ctx, throttled.RetryAfter)
+			return ctx.Status(429).JSON(fiber.Map{
+				"error":       "Verification email was sent recently",
+				"retry_after": retryAfter,
+			})
+		}
+		log.Printf("Error resending verification email: %v", err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "If the account exists and is not yet verified, a new verification link has been sent",
+	})
+}
+
+// @Summary Request password reset
+// @Description Send a single-use password reset link to the account email. Always succeeds so that registered emails cannot be discovered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.ForgotPasswordRequest true "Forgot password request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/auth/forgot-password [post]
+func (c *AuthController) ForgotPassword(ctx *fiber.Ctx) error {
+	var req dto.ForgotPasswordRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	if err := c.authService.RequestPasswordReset(req.Email); err != nil {
+		log.Printf("Error requesting password reset: %v", err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// @Summary Reset password
+// @Description Set a new password using a reset token. All existing sessions of the user are revoked.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.ResetPasswordRequest true "Reset password request"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/auth/reset-password [post]
+func (c *AuthController) ResetPassword(ctx *fiber.Ctx) error {
+	var req dto.ResetPasswordRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	userID, err := c.authService.ResetPassword(req.Token, req.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			event := newSecurityEvent(ctx, models.SecurityEventPasswordReset, models.SecurityOutcomeFailure)
+			event.Detail = err.Error()
+			c.securityEvents.Record(event)
+		}
+
+		var policyErr *services.PasswordPolicyError
+		if errors.Is(err, services.ErrInvalidResetToken) || errors.As(err, &policyErr) {
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to reset password",
+		})
+	}
+
+	event := newSecurityEvent(ctx, models.SecurityEventPasswordReset, models.SecurityOutcomeSuccess)
+	event.UserID = &userID
+	c.securityEvents.Record(event)
+
+	return ctx.JSON(fiber.Map{
+		"message": "Password has been reset successfully",
+	})
+}
+
+// @Summary Get user profile
+// @Description Get current user profile
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} dto.UserResponse
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/auth/profile [get]
+func (c *AuthController) GetProfile(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	user, err := c.authService.GetUserByID(userID)
+	if err != nil {
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	response := convertUserToResponse(*user)
+
+	return ctx.JSON(response)
+}
+
+// @Summary Update user profile
+// @Description Update current user profile
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body dto.UpdateProfileRequest true "Update profile request"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/auth/profile [put]
+func (c *AuthController) UpdateProfile(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req dto.UpdateProfileRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	err := c.authService.UpdateProfile(userID, req.FirstName, req.LastName, req.Phone)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to update profile",
+		})
+	}
+
+	user, err := c.authService.GetUserByID(userID)
+	if err != nil {
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	response := convertUserToResponse(*user)
+
+	return ctx.JSON(response)
+}
+
+// @Summary List security events
+// @Description Get the security events on the current user's account, such as logins, failed login attempts, password changes and revoked sessions, newest first
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(20) minimum(1) maximum(100)
+// @Success 200 {object} dto.SecurityEventListResponse
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/auth/security-events [get]
+func (c *AuthController) GetSecurityEvents(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	events, total, err := c.securityEvents.GetUserEvents(userID, page, limit)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch security events",
+		})
+	}
+
+	return ctx.JSON(convertSecurityEventsToResponse(events, total, page, limit))
+}
+
+// @Summary Export personal data
+// @Description Download everything stored about the current user as a ZIP archive with one JSON file per section. Pass format=json to get a single JSON document instead.
+// @Tags auth
+// @Security BearerAuth
+// @Produce application/zip
+// @Produce json
+// @Param format query string false "Archive format" Enums(zip, json)
+// @Success 200 {file} file
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/auth/me/export [get]
+func (c *AuthController) ExportData(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	if ctx.Query("format") == "json" {
+		export, err := c.privacyService.ExportData(userID)
+		if err != nil {
+			log.Printf("Failed to export data of user %d: %v", userID, err)
+			return ctx.Status(500).JSON(fiber.Map{
+				"error": "Failed to export data",
+			})
+		}
+		return ctx.JSON(export)
+	}
+
+	archive, err := c.privacyService.ExportArchive(userID)
+	if err != nil {
+		log.Printf("Failed to export data of user %d: %v", userID, err)
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to export data",
+		})
+	}
+
+	ctx.Set(fiber.HeaderContentType, "application/zip")
+	ctx.Attachment("personal-data-" + strconv.FormatUint(uint64(userID), 10) + ".zip")
+	return ctx.Send(archive)
+}
+
+// @Summary Delete account
+// @Description Request deletion of the current user's account. The account is deactivated and logged out at once, then anonymised or deleted once the grace period has passed.
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body dto.DeleteAccountRequest true "Password confirmation and optional reason"
+// @Success 202 {object} dto.AccountDeletionResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/auth/me [delete]
+func (c *AuthController) DeleteAccount(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user_id").(uint)
+
+	var req dto.DeleteAccountRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	deletion, err := c.privacyService.RequestDeletion(userID, req.Password, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPassword):
+			return ctx.Status(401).JSON(fiber.Map{
+				"error": "Password is incorrect",
+			})
+		case errors.Is(err, services.ErrDeletionAlreadyRequested):
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return ctx.Status(500).JSON(fiber.Map{
+				"error": "Failed to delete account",
+			})
+		}
+	}
+
+	event := newSecurityEvent(ctx, models.SecurityEventAccountDeleted, models.SecurityOutcomeSuccess)
+	event.Detail = "deletion scheduled for " + deletion.ScheduledFor.Format(time.RFC3339)
+	c.securityEvents.Record(event)
+
+	return ctx.Status(202).JSON(dto.AccountDeletionResponse{
+		Method:       deletion.Method,
+		RequestedAt:  deletion.RequestedAt,
+		ScheduledFor: deletion.ScheduledFor,
+		Message:      "Your account has been deactivated and will be deleted after the grace period",
+	})
+}
+
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens, selected by the kid header. Retired keys stay listed until tokens signed with them have expired.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} services.JWKS
+// @Router /.well-known/jwks.json [get]
+func (c *AuthController) JWKS(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(services.JWKSCacheMaxAge.Seconds())))
+	return ctx.JSON(services.GetJWTKeys().JWKS())
 }
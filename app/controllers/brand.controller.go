@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type BrandController struct {
+	brandService   *services.BrandService
+	productService *services.ProductService
+	products       *ProductController
+	validate       *validator.Validate
+}
+
+func NewBrandController() *BrandController {
+	return &BrandController{
+		brandService:   services.NewBrandService(),
+		productService: services.NewProductService(),
+		products:       NewProductController(),
+		validate:       validator.New(),
+	}
+}
+
+// @Summary Get brands
+// @Description Get the active brands by name with the number of active products of each
+// @Tags brands
+// @Produce json
+// @Success 200 {array} dto.BrandResponse "Success"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/brands [get]
+func (c *BrandController) GetBrands(ctx *fiber.Ctx) error {
+	brands, err := c.brandService.GetBrands()
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch brands",
+		})
+	}
+
+	ctx.Set("Cache-Control", "public, max-age=1800") // 30 minutes
+
+	return ctx.JSON(convertBrandsToResponses(brands))
+}
+
+// @Summary Get brand
+// @Description Get an active brand with the number of its active products
+// @Tags brands
+// @Produce json
+// @Param id path int true "Brand ID" minimum(1)
+// @Success 200 {object} dto.BrandResponse "Success"
+// @Failure 400 {object} map[string]interface{} "Bad Request - Invalid brand ID"
+// @Failure 404 {object} map[string]interface{} "Not Found - Brand not found"
+// @Router /api/brands/{id} [get]
+func (c *BrandController) GetBrand(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid brand ID",
+		})
+	}
+
+	brand, err := c.brandService.GetBrand(uint(id))
+	if err != nil {
+		return brandErrorResponse(ctx, err, "Failed to fetch brand")
+	}
+
+	return ctx.JSON(convertBrandToResponse(*brand))
+}
+
+// @Summary Get products by brand
+// @Description Get the active products of an active brand with pagination
+// @Tags brands
+// @Produce json
+// @Param id path int true "Brand ID" minimum(1)
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Success 200 {object} dto.ProductListResponse "Success"
+// @Failure 400 {object} map[string]interface{} "Bad Request - Invalid brand ID"
+// @Failure 404 {object} map[string]interface{} "Not Found - Brand not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/brands/{id}/products [get]
+func (c *BrandController) GetBrandProducts(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid brand ID",
+		})
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	brandID := uint(id)
+
+	if _, err := c.brandService.GetBrand(brandID); err != nil {
+		return brandErrorResponse(ctx, err, "Failed to fetch brand")
+	}
+
+	products, total, err := c.productService.GetProductsByBrand(brandID, page, limit)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch products",
+		})
+	}
+
+	// Generate ETag for caching
+	etag := fmt.Sprintf("brand-products-%d-%d-%d-%d", brandID, page, limit, total)
+	if ctx.Get("If-None-Match") == etag {
+		return ctx.SendStatus(304) // Not Modified
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	response := dto.ProductListResponse{
+		Products: c.products.convertProductsToResponses(products),
+		Pagination: dto.PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
+	}
+
+	// Set cache headers
+	ctx.Set("ETag", etag)
+	ctx.Set("Cache-Control", "public, max-age=300") // 5 minutes
+
+	return ctx.JSON(response)
+}
+
+// @Summary Get all brands (Admin)
+// @Description Get every brand, including inactive ones, with its aliases and product count
+// @Tags admin
+// @Produce json
+// @Success 200 {array} dto.BrandResponse "Success"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /admin/api/brands [get]
+func (c *BrandController) GetAdminBrands(ctx *fiber.Ctx) error {
+	brands, err := c.brandService.GetAdminBrands()
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch brands",
+		})
+	}
+
+	return ctx.JSON(convertBrandsToResponses(brands))
+}
+
+// @Summary Create brand
+// @Description Add a brand. Its name is normalised and becomes its first alias; a name that matches the spelling of an existing brand, ignoring case, punctuation and company forms such as "Inc.", is refused. The logo is a file name in /assets/images/Brands or an absolute http(s) URL.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param brand body dto.CreateBrandRequest true "Brand"
+// @Success 201 {object} dto.BrandResponse "Brand created"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 409 {object} map[string]interface{} "Brand already exists"
+// @Router /admin/api/brands [post]
+func (c *BrandController) CreateBrand(ctx *fiber.Ctx) error {
+	var req dto.CreateBrandRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	brand, err := c.brandService.CreateBrand(req)
+	if err != nil {
+		return brandErrorResponse(ctx, err, "Failed to create brand")
+	}
+
+	return ctx.Status(201).JSON(convertBrandToResponse(*brand))
+}
+
+// @Summary Update brand
+// @Description Change the name, logo, description or active flag of a brand. A new name is kept as an alias and copied to the brand's products.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Brand ID" minimum(1)
+// @Param brand body dto.UpdateBrandRequest true "Brand changes"
+// @Success 200 {object} dto.BrandResponse "Brand updated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Brand not found"
+// @Failure 409 {object} map[string]interface{} "Name belongs to another brand"
+// @Router /admin/api/brands/{id} [put]
+func (c *BrandController) UpdateBrand(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid brand ID",
+		})
+	}
+
+	var req dto.UpdateBrandRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	brand, err := c.brandService.UpdateBrand(uint(id), req)
+	if err != nil {
+		return brandErrorResponse(ctx, err, "Failed to update brand")
+	}
+
+	return ctx.JSON(convertBrandToResponse(*brand))
+}
+
+// @Summary Add brand alias
+// @Description Record another spelling of a brand, such as "HP" for "Hewlett-Packard". Products created or uploaded with that spelling get the brand.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Brand ID" minimum(1)
+// @Param alias body dto.AddBrandAliasRequest true "Alias"
+// @Success 201 {object} dto.BrandResponse "Alias added"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Brand not found"
+// @Failure 409 {object} map[string]interface{} "Spelling belongs to another brand"
+// @Router /admin/api/brands/{id}/aliases [post]
+func (c *BrandController) AddBrandAlias(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid brand ID",
+		})
+	}
+
+	var req dto.AddBrandAliasRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	brand, err := c.brandService.AddAlias(uint(id), req.Alias)
+	if err != nil {
+		return brandErrorResponse(ctx, err, "Failed to add alias")
+	}
+
+	return ctx.Status(201).JSON(convertBrandToResponse(*brand))
+}
+
+// @Summary Remove brand alias
+// @Description Delete a spelling of a brand. Products that already have the brand keep it; the alias of the brand's own name cannot be removed.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Brand ID" minimum(1)
+// @Param aliasId path int true "Alias ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "Alias removed"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Brand or alias not found"
+// @Router /admin/api/brands/{id}/aliases/{aliasId} [delete]
+func (c *BrandController) RemoveBrandAlias(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid brand ID",
+		})
+	}
+
+	aliasID, err := strconv.ParseUint(ctx.Params("aliasId"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid alias ID",
+		})
+	}
+
+	if err := c.brandService.RemoveAlias(uint(id), uint(aliasID)); err != nil {
+		return brandErrorResponse(ctx, err, "Failed to remove alias")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Alias removed successfully",
+	})
+}
+
+func brandErrorResponse(ctx *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "Brand or alias not found",
+		})
+	case errors.Is(err, services.ErrInvalidBrandName), errors.Is(err, services.ErrInvalidImageFile),
+		errors.Is(err, services.ErrBrandAliasIsName):
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrBrandExists), errors.Is(err, services.ErrBrandAliasTaken):
+		return ctx.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+}
+
+func convertBrandsToResponses(brands []models.Brand) []dto.BrandResponse {
+	responses := make([]dto.BrandResponse, len(brands))
+	for i, brand := range brands {
+		responses[i] = convertBrandToResponse(brand)
+	}
+	return responses
+}
+
+func convertBrandToResponse(brand models.Brand) dto.BrandResponse {
+	response := dto.BrandResponse{
+		ID:           brand.ID,
+		Name:         brand.Name,
+		Slug:         brand.Slug,
+		Logo:         brand.Logo,
+		LogoURL:      brandLogoURL(brand.Logo),
+		Description:  brand.Description,
+		Active:       brand.Active,
+		ProductCount: brand.ProductCount,
+	}
+	for _, alias := range brand.Aliases {
+		response.Aliases = append(response.Aliases, dto.BrandAliasResponse{ID: alias.ID, Alias: alias.Alias})
+	}
+	return response
+}
+
+// brandLogoURL returns the URL of a brand logo; absolute URLs are used as they are
+func brandLogoURL(file string) string {
+	switch {
+	case file == "":
+		return ""
+	case strings.HasPrefix(file, "http://"), strings.HasPrefix(file, "https://"):
+		return file
+	default:
+		return fmt.Sprintf("/assets/images/Brands/%s", file)
+	}
+}
@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type CategoryController struct {
+	categoryService *services.CategoryService
+	validate        *validator.Validate
+}
+
+func NewCategoryController() *CategoryController {
+	return &CategoryController{
+		categoryService: services.NewCategoryService(),
+		validate:        validator.New(),
+	}
+}
+
+// @Summary Get category tree
+// @Description Get the active categories as a tree, with the subcategories of each category nested in children and sorted by name
+// @Tags categories
+// @Produce json
+// @Success 200 {array} dto.CategoryTreeResponse "Success"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /api/categories/tree [get]
+func (c *CategoryController) GetCategoryTree(ctx *fiber.Ctx) error {
+	tree, err := c.categoryService.GetCategoryTree()
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch category tree",
+		})
+	}
+
+	// Set cache headers
+	ctx.Set("Cache-Control", "public, max-age=1800") // 30 minutes for categories
+
+	return ctx.JSON(convertCategoryTree(tree))
+}
+
+func convertCategoryTree(categories []models.Category) []dto.CategoryTreeResponse {
+	nodes := make([]dto.CategoryTreeResponse, len(categories))
+	for i, category := range categories {
+		nodes[i] = dto.CategoryTreeResponse{
+			ID:           category.ID,
+			Name:         category.Name,
+			Description:  category.Description,
+			Slug:         category.Slug,
+			ProductCount: category.ProductCount,
+			Children:     convertCategoryTree(category.Children),
+		}
+	}
+	return nodes
+}
+
+// @Summary Create category
+// @Description Add a category at the root of the tree or below parent_id. The slug is derived from the name.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param category body dto.CreateCategoryRequest true "Category data"
+// @Success 201 {object} dto.CategoryResponse "Category created"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 409 {object} map[string]interface{} "Name already used below the same parent"
+// @Router /admin/api/categories [post]
+func (c *CategoryController) CreateCategory(ctx *fiber.Ctx) error {
+	var req dto.CreateCategoryRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	category, err := c.categoryService.CreateCategory(req)
+	if err != nil {
+		return categoryErrorResponse(ctx, err, "Failed to create category")
+	}
+
+	return ctx.Status(201).JSON(convertCategoryToResponse(*category))
+}
+
+// @Summary Update category
+// @Description Rename, describe or move a category. A parent_id of 0 moves it to the root. A new name is also stored on the category's products.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID" minimum(1)
+// @Param category body dto.UpdateCategoryRequest true "Category changes"
+// @Success 200 {object} dto.CategoryResponse "Category updated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "Name already used below the same parent"
+// @Router /admin/api/categories/{id} [put]
+func (c *CategoryController) UpdateCategory(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	var req dto.UpdateCategoryRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	category, err := c.categoryService.UpdateCategory(uint(id), req)
+	if err != nil {
+		return categoryErrorResponse(ctx, err, "Failed to update category")
+	}
+
+	return ctx.JSON(convertCategoryToResponse(*category))
+}
+
+// @Summary Deactivate category
+// @Description Hide a category and its subcategories from the public category listings. Its products keep their category.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Category ID" minimum(1)
+// @Success 200 {object} dto.CategoryResponse "Category deactivated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Router /admin/api/categories/{id}/deactivate [post]
+func (c *CategoryController) DeactivateCategory(ctx *fiber.Ctx) error {
+	return c.setCategoryActive(ctx, false)
+}
+
+// @Summary Reactivate category
+// @Description Show a deactivated category in the public category listings again
+// @Tags admin
+// @Produce json
+// @Param id path int true "Category ID" minimum(1)
+// @Success 200 {object} dto.CategoryResponse "Category reactivated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Router /admin/api/categories/{id}/reactivate [post]
+func (c *CategoryController) ReactivateCategory(ctx *fiber.Ctx) error {
+	return c.setCategoryActive(ctx, true)
+}
+
+func (c *CategoryController) setCategoryActive(ctx *fiber.Ctx, active bool) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	category, err := c.categoryService.SetCategoryActive(uint(id), active)
+	if err != nil {
+		return categoryErrorResponse(ctx, err, "Failed to update category")
+	}
+
+	return ctx.JSON(convertCategoryToResponse(*category))
+}
+
+// @Summary Delete category
+// @Description Delete an empty category together with its attributes. A category with products or subcategories has to be merged into another one instead.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Category ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "Category deleted"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "Category is not empty"
+// @Router /admin/api/categories/{id} [delete]
+func (c *CategoryController) DeleteCategory(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	if err := c.categoryService.DeleteCategory(uint(id)); err != nil {
+		return categoryErrorResponse(ctx, err, "Failed to delete category")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Category deleted successfully",
+	})
+}
+
+// @Summary Merge category
+// @Description Move every product and subcategory of a category into the target category in one transaction, then delete it. Products get the target's ID and name; attribute values are kept where the target has an attribute with the same key and type.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "ID of the category to merge away" minimum(1)
+// @Param merge body dto.MergeCategoryRequest true "Target category"
+// @Success 200 {object} dto.CategoryMergeResponse "Categories merged"
+// @Failure 400 {object} map[string]interface{} "Bad Request - Target is the category itself or one of its subcategories"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "Both categories have a subcategory with the same name"
+// @Router /admin/api/categories/{id}/merge [post]
+func (c *CategoryController) MergeCategory(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid category ID",
+		})
+	}
+
+	var req dto.MergeCategoryRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	result, err := c.categoryService.MergeCategory(uint(id), req.TargetID)
+	if err != nil {
+		return categoryErrorResponse(ctx, err, "Failed to merge categories")
+	}
+
+	return ctx.JSON(dto.CategoryMergeResponse{
+		Category:           convertCategoryToResponse(*result.Category),
+		ProductsMoved:      result.ProductsMoved,
+		SubcategoriesMoved: result.SubcategoriesMoved,
+	})
+}
+
+func categoryErrorResponse(ctx *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	case errors.Is(err, services.ErrInvalidCategoryName),
+		errors.Is(err, services.ErrCategoryParentInvalid),
+		errors.Is(err, services.ErrCategoryMergeInvalid):
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrCategoryExists),
+		errors.Is(err, services.ErrCategoryNotEmpty),
+		errors.Is(err, services.ErrCategoryMergeConflict):
+		return ctx.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+}
+
+func convertCategoryToResponse(category models.Category) dto.CategoryResponse {
+	return dto.CategoryResponse{
+		ID:           category.ID,
+		ParentID:     category.ParentID,
+		Name:         category.Name,
+		Description:  category.Description,
+		Slug:         category.Slug,
+		Active:       category.Active,
+		ProductCount: category.ProductCount,
+	}
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type ImpersonationController struct {
+	impersonations *services.ImpersonationService
+	authService    *services.AuthService
+	securityEvents *services.SecurityEventService
+	validate       *validator.Validate
+}
+
+func NewImpersonationController() *ImpersonationController {
+	return &ImpersonationController{
+		impersonations: services.NewImpersonationService(),
+		authService:    services.NewAuthService(),
+		securityEvents: services.NewSecurityEventService(),
+		validate:       validator.New(),
+	}
+}
+
+// @Summary Impersonate user
+// @Description Get a short-lived access token to act as a user and reproduce their issue. The token names the admin, cannot be refreshed and cannot change the password, two-factor settings or sessions, export data or delete the account. Every response made with it carries the X-Impersonated-By header. Users with admin permissions and service accounts cannot be impersonated.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Param body body dto.ImpersonateRequest true "Reason for the impersonation"
+// @Success 201 {object} dto.ImpersonationResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /admin/api/users/{id}/impersonate [post]
+func (c *ImpersonationController) ImpersonateUser(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	// Only a person can take responsibility for acting as a user
+	if _, isAPIKey := ctx.Locals("api_key_id").(uint); isAPIKey {
+		return ctx.Status(403).JSON(fiber.Map{
+			"error": "API keys cannot impersonate users",
+		})
+	}
+
+	var req dto.ImpersonateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	adminID := ctx.Locals("user_id").(uint)
+	impersonation, err := c.impersonations.Start(adminID, uint(id), req.Reason, deviceFromContext(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "User not found or inactive",
+			})
+		case errors.Is(err, services.ErrImpersonateSelf):
+			return ctx.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrImpersonateServiceAccount),
+			errors.Is(err, services.ErrImpersonatePrivileged):
+			return ctx.Status(403).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return ctx.Status(500).JSON(fiber.Map{
+				"error": "Failed to start impersonation",
+			})
+		}
+	}
+
+	event := newAdminSecurityEvent(ctx, models.SecurityEventImpersonation, uint(id))
+	event.Detail = req.Reason
+	c.securityEvents.Record(event)
+
+	user, err := c.authService.GetUserByID(uint(id))
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
+
+	return ctx.Status(201).JSON(dto.ImpersonationResponse{
+		Impersonation: true,
+		Token:         impersonation.Token,
+		ExpiresIn:     impersonation.ExpiresIn,
+		User:          convertUserToResponse(*user),
+		Session: convertImpersonationToResponse(services.ImpersonationRecord{
+			ImpersonationSession: impersonation.Session,
+			AdminEmail:           ctx.Locals("user_email").(string),
+			UserEmail:            user.Email,
+		}),
+	})
+}
+
+// @Summary List impersonation sessions
+// @Description Get the audit trail of admins acting as users, newest first
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(50) minimum(1) maximum(200)
+// @Param admin_id query int false "Filter by admin"
+// @Param user_id query int false "Filter by impersonated user"
+// @Param active query bool false "Only sessions whose token is still valid"
+// @Success 200 {object} dto.ImpersonationListResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /admin/api/impersonations [get]
+func (c *ImpersonationController) GetImpersonations(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	filter := services.ImpersonationFilter{
+		ActiveOnly: ctx.QueryBool("active"),
+		Page:       page,
+		Limit:      limit,
+	}
+
+	var err error
+	if filter.AdminID, err = parseIDQuery(ctx, "admin_id"); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid admin_id",
+		})
+	}
+	if filter.UserID, err = parseIDQuery(ctx, "user_id"); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user_id",
+		})
+	}
+
+	records, total, err := c.impersonations.GetSessions(filter)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch impersonation sessions",
+		})
+	}
+
+	sessions := make([]dto.ImpersonationSessionResponse, len(records))
+	for i, record := range records {
+		sessions[i] = convertImpersonationToResponse(record)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return ctx.JSON(dto.ImpersonationListResponse{
+		Sessions: sessions,
+		Pagination: dto.PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
+	})
+}
+
+// @Summary End impersonation session
+// @Description Revoke an impersonation token before it expires
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Impersonation session ID" minimum(1)
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /admin/api/impersonations/{id} [delete]
+func (c *ImpersonationController) EndImpersonation(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid impersonation session ID",
+		})
+	}
+
+	session, err := c.impersonations.End(uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "Impersonation session not found",
+			})
+		case errors.Is(err, services.ErrImpersonationEnded):
+			return ctx.Status(409).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return ctx.Status(500).JSON(fiber.Map{
+				"error": "Failed to end impersonation",
+			})
+		}
+	}
+
+	c.securityEvents.Record(newAdminSecurityEvent(ctx, models.SecurityEventImpersonationEnd, session.UserID))
+
+	return ctx.JSON(fiber.Map{
+		"message": "Impersonation ended",
+	})
+}
+
+// parseIDQuery reads an optional numeric ID query parameter
+func parseIDQuery(ctx *fiber.Ctx, name string) (*uint, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	result := uint(id)
+	return &result, nil
+}
+
+func convertImpersonationToResponse(record services.ImpersonationRecord) dto.ImpersonationSessionResponse {
+	return dto.ImpersonationSessionResponse{
+		ID:         record.ID,
+		SessionID:  record.SessionID,
+		AdminID:    record.AdminID,
+		AdminEmail: record.AdminEmail,
+		UserID:     record.UserID,
+		UserEmail:  record.UserEmail,
+		Reason:     record.Reason,
+		IP:         record.IP,
+		UserAgent:  record.UserAgent,
+		StartedAt:  record.StartedAt,
+		ExpiresAt:  record.ExpiresAt,
+		EndedAt:    record.EndedAt,
+		Active:     record.Active(),
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,12 +29,6 @@ func NewProductController() *ProductController {
 
 // Helper function to convert product to response DTO
 func (c *ProductController) convertProductToResponse(product models.Product) dto.ProductResponse {
-	// Generate image URL
-	imageURL := ""
-	if product.Image != "" {
-		imageURL = fmt.Sprintf("/assets/images/Products/%s", product.Image)
-	}
-
 	response := dto.ProductResponse{
 		ID:               product.ID,
 		Index:            product.Index,
@@ -40,6 +36,7 @@ func (c *ProductController) convertProductToResponse(product models.Product) dto
 		Description:      product.Description,
 		ShortDescription: product.ShortDescription,
 		Brand:            product.Brand,
+		BrandID:          product.BrandID,
 		Category:         product.Category,
 		Price:            product.Price,
 		Currency:         product.Currency,
@@ -49,29 +46,201 @@ func (c *ProductController) convertProductToResponse(product models.Product) dto
 		Size:             product.Size,
 		Availability:     product.Availability,
 		Image:            product.Image,
-		ImageURL:         imageURL,
+		ImageURL:         productImageURL(product.Image),
 		InternalID:       product.InternalID,
 		Slug:             product.Slug,
 		SKU:              product.SKU,
+		GroupKey:         product.GroupKey,
+		Variants:         convertVariantsToResponses(product),
+		Images:           convertImagesToResponses(product.Images),
+		Attributes:       convertAttributesToResponses(product.Attributes),
 		Active:           product.Active,
 		CreatedAt:        product.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:        product.UpdatedAt.Format(time.RFC3339),
 	}
 
+	// A product with variants has the stock of its variants
+	if len(product.Variants) > 0 {
+		response.Stock = totalVariantStock(product.Variants)
+	}
+
+	if product.BrandModel != nil {
+		brand := convertBrandToResponse(*product.BrandModel)
+		response.BrandModel = &brand
+	}
+
 	// Add category model if available
 	if product.CategoryModel.ID != 0 {
 		response.CategoryModel = dto.CategoryResponse{
 			ID:          product.CategoryModel.ID,
+			ParentID:    product.CategoryModel.ParentID,
 			Name:        product.CategoryModel.Name,
 			Description: product.CategoryModel.Description,
 			Slug:        product.CategoryModel.Slug,
 			Active:      product.CategoryModel.Active,
 		}
 	}
+	response.Breadcrumbs = convertBreadcrumbs(product.Breadcrumbs)
 
 	return response
 }
 
+// convertBreadcrumbs lists the categories from the root down to a product's category
+func convertBreadcrumbs(categories []models.Category) []dto.CategoryBreadcrumb {
+	breadcrumbs := make([]dto.CategoryBreadcrumb, len(categories))
+	for i, category := range categories {
+		breadcrumbs[i] = dto.CategoryBreadcrumb{
+			ID:   category.ID,
+			Name: category.Name,
+			Slug: category.Slug,
+		}
+	}
+	return breadcrumbs
+}
+
+// convertVariantsToResponses lists a product's variants with the price each one sells at
+func convertVariantsToResponses(product models.Product) []dto.ProductVariantResponse {
+	variants := make([]dto.ProductVariantResponse, len(product.Variants))
+	for i, variant := range product.Variants {
+		variants[i] = convertVariantToResponse(variant, product.Price)
+	}
+	return variants
+}
+
+func convertVariantToResponse(variant models.ProductVariant, productPrice float64) dto.ProductVariantResponse {
+	return dto.ProductVariantResponse{
+		ID:            variant.ID,
+		SKU:           variant.SKU,
+		EAN:           variant.EAN,
+		Color:         variant.Color,
+		Size:          variant.Size,
+		Price:         variant.EffectivePrice(productPrice),
+		PriceOverride: variant.Price,
+		Stock:         variant.Stock,
+		Image:         variant.Image,
+		ImageURL:      productImageURL(variant.Image),
+		Position:      variant.Position,
+		Active:        variant.Active,
+	}
+}
+
+func convertImagesToResponses(images []models.ProductImage) []dto.ProductImageResponse {
+	responses := make([]dto.ProductImageResponse, len(images))
+	for i, image := range images {
+		responses[i] = convertImageToResponse(image)
+	}
+	return responses
+}
+
+func convertImageToResponse(image models.ProductImage) dto.ProductImageResponse {
+	return dto.ProductImageResponse{
+		ID:       image.ID,
+		File:     image.File,
+		URL:      productImageURL(image.File),
+		AltText:  image.AltText,
+		Position: image.Position,
+		Primary:  image.IsPrimary,
+		Width:    image.Width,
+		Height:   image.Height,
+	}
+}
+
+// productImageURL returns the URL of a product image; absolute URLs are used as they are
+func productImageURL(file string) string {
+	switch {
+	case file == "":
+		return ""
+	case strings.HasPrefix(file, "http://"), strings.HasPrefix(file, "https://"):
+		return file
+	default:
+		return fmt.Sprintf("/assets/images/Products/%s", file)
+	}
+}
+
+// convertAttributesToResponses lists a product's attributes in the order of their definitions
+func convertAttributesToResponses(values []models.ProductAttributeValue) []dto.ProductAttributeResponse {
+	sorted := make([]models.ProductAttributeValue, len(values))
+	copy(sorted, values)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Attribute.Position != sorted[j].Attribute.Position {
+			return sorted[i].Attribute.Position < sorted[j].Attribute.Position
+		}
+		return sorted[i].Attribute.ID < sorted[j].Attribute.ID
+	})
+
+	responses := make([]dto.ProductAttributeResponse, len(sorted))
+	for i, value := range sorted {
+		responses[i] = dto.ProductAttributeResponse{
+			Key:   value.Attribute.Key,
+			Name:  value.Attribute.Name,
+			Type:  value.Attribute.Type,
+			Unit:  value.Attribute.Unit,
+			Value: value.Value(),
+		}
+	}
+	return responses
+}
+
+// parseAttributeFilters reads attribute filters from attr.<key>, attr.<key>.min and attr.<key>.max query parameters
+func parseAttributeFilters(ctx *fiber.Ctx) ([]services.AttributeFilter, error) {
+	filters := make(map[string]*services.AttributeFilter)
+	filterFor := func(key string) *services.AttributeFilter {
+		if filters[key] == nil {
+			filters[key] = &services.AttributeFilter{Key: key}
+		}
+		return filters[key]
+	}
+
+	for name, value := range ctx.Queries() {
+		key, found := strings.CutPrefix(name, "attr.")
+		if !found || key == "" || value == "" {
+			continue
+		}
+
+		if base, bound, isRange := strings.Cut(key, "."); isRange {
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil || (bound != "min" && bound != "max") {
+				return nil, fmt.Errorf("invalid attribute filter %s", name)
+			}
+			if bound == "min" {
+				filterFor(base).Min = &number
+			} else {
+				filterFor(base).Max = &number
+			}
+			continue
+		}
+
+		for _, option := range strings.Split(value, ",") {
+			if option = strings.TrimSpace(option); option != "" {
+				filterFor(key).Values = append(filterFor(key).Values, option)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]services.AttributeFilter, len(keys))
+	for i, key := range keys {
+		result[i] = *filters[key]
+	}
+	return result, nil
+}
+
+// totalVariantStock adds up the stock of the variants that are for sale
+func totalVariantStock(variants []models.ProductVariant) int {
+	total := 0
+	for _, variant := range variants {
+		if variant.Active {
+			total += variant.Stock
+		}
+	}
+	return total
+}
+
 // Helper function to convert products to responses with concurrent processing for large datasets
 func (c *ProductController) convertProductsToResponses(products []models.Product) []dto.ProductResponse {
 	if len(products) <= 50 {
@@ -140,7 +309,7 @@ func (c *ProductController) GetProducts(ctx *fiber.Ctx) error {
 		}
 	}
 
-	products, total, err := c.productService.GetProducts(page, limit, categoryID)
+	products, total, err := c.productService.GetProducts(page, limit, categoryID, false)
 	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{
 			"error": "Failed to fetch products",
@@ -236,6 +405,9 @@ func (c *ProductController) GetProductByID(ctx *fiber.Ctx) error {
 // @Param sort_order query string false "Sort order" Enums(ASC, DESC) default(DESC)
 // @Param page query int false "Page number" default(1) minimum(1)
 // @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
+// @Param attr.{key} query string false "Attribute value filter, comma separated for any of several values (e.g. attr.material=cotton,linen)"
+// @Param attr.{key}.min query number false "Minimum value of a numeric attribute (e.g. attr.ram.min=16)"
+// @Param attr.{key}.max query number false "Maximum value of a numeric attribute"
 // @Success 200 {object} dto.ProductListResponse "Success"
 // @Failure 400 {object} map[string]interface{} "Bad Request"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
@@ -254,7 +426,14 @@ func (c *ProductController) SearchProducts(ctx *fiber.Ctx) error {
 	sortBy := ctx.Query("sort_by")
 	sortOrder := ctx.Query("sort_order")
 
-	products, total, err := c.productService.SearchProducts(query, category, minPrice, maxPrice, sortBy, sortOrder, page, limit)
+	attributes, err := parseAttributeFilters(ctx)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	products, total, err := c.productService.SearchProducts(query, category, minPrice, maxPrice, sortBy, sortOrder, page, limit, attributes)
 	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{
 			"error": "Failed to search products",
@@ -262,7 +441,7 @@ func (c *ProductController) SearchProducts(ctx *fiber.Ctx) error {
 	}
 
 	// Generate ETag for caching
-	etag := fmt.Sprintf("search-%s-%s-%s-%s-%s-%s-%d-%d-%d", query, category, minPrice, maxPrice, sortBy, sortOrder, page, limit, total)
+	etag := fmt.Sprintf("search-%s-%s-%s-%s-%s-%s-%d-%d-%d-%x", query, category, minPrice, maxPrice, sortBy, sortOrder, page, limit, total, ctx.Context().QueryArgs().QueryString())
 	if ctx.Get("If-None-Match") == etag {
 		return ctx.SendStatus(304) // Not Modified
 	}
@@ -293,7 +472,7 @@ func (c *ProductController) SearchProducts(ctx *fiber.Ctx) error {
 }
 
 // @Summary Get categories
-// @Description Get list of all active categories
+// @Description Get list of all active categories with the number of active products in each
 // @Tags categories
 // @Accept json
 // @Produce json
@@ -318,20 +497,18 @@ func (c *ProductController) GetCategories(ctx *fiber.Ctx) error {
 	fmt.Printf("Debug: Got %d categories from service\n", len(categories))
 
 	// Generate ETag for caching
-	etag := fmt.Sprintf("categories-%d", len(categories))
+	var productCount int64
+	for _, category := range categories {
+		productCount += category.ProductCount
+	}
+	etag := fmt.Sprintf("categories-%d-%d", len(categories), productCount)
 	if ctx.Get("If-None-Match") == etag {
 		return ctx.SendStatus(304) // Not Modified
 	}
 
 	var categoryResponses []dto.CategoryResponse
 	for _, category := range categories {
-		categoryResponses = append(categoryResponses, dto.CategoryResponse{
-			ID:          category.ID,
-			Name:        category.Name,
-			Description: category.Description,
-			Slug:        category.Slug,
-			Active:      category.Active,
-		})
+		categoryResponses = append(categoryResponses, convertCategoryToResponse(category))
 	}
 
 	fmt.Printf("Debug: Returning %d category responses\n", len(categoryResponses))
@@ -344,11 +521,12 @@ func (c *ProductController) GetCategories(ctx *fiber.Ctx) error {
 }
 
 // @Summary Get products by category
-// @Description Get products filtered by category ID with pagination
+// @Description Get products filtered by category ID with pagination. With include_descendants the products of all active subcategories are included.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID" minimum(1)
+// @Param include_descendants query bool false "Include products of subcategories at any depth" default(false)
 // @Param page query int false "Page number" default(1) minimum(1)
 // @Param limit query int false "Items per page" default(10) minimum(1) maximum(100)
 // @Success 200 {object} dto.ProductListResponse "Success"
@@ -371,8 +549,9 @@ func (c *ProductController) GetProductsByCategory(ctx *fiber.Ctx) error {
 	page, _ := strconv.Atoi(ctx.Query("page", "1"))
 	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
 	categoryID := uint(id)
+	includeDescendants := ctx.QueryBool("include_descendants", false)
 
-	products, total, err := c.productService.GetProducts(page, limit, &categoryID)
+	products, total, err := c.productService.GetProducts(page, limit, &categoryID, includeDescendants)
 	if err != nil {
 		return ctx.Status(500).JSON(fiber.Map{
 			"error": "Failed to fetch products",
@@ -380,7 +559,7 @@ func (c *ProductController) GetProductsByCategory(ctx *fiber.Ctx) error {
 	}
 
 	// Generate ETag for caching
-	etag := fmt.Sprintf("category-products-%d-%d-%d-%d", categoryID, page, limit, total)
+	etag := fmt.Sprintf("category-products-%d-%t-%d-%d-%d", categoryID, includeDescendants, page, limit, total)
 	if ctx.Get("If-None-Match") == etag {
 		return ctx.SendStatus(304) // Not Modified
 	}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type ProductImageController struct {
+	imageService *services.ProductImageService
+	validate     *validator.Validate
+}
+
+func NewProductImageController() *ProductImageController {
+	return &ProductImageController{
+		imageService: services.NewProductImageService(),
+		validate:     validator.New(),
+	}
+}
+
+// @Summary Attach product image
+// @Description Add an image to the end of a product's gallery. The file is a file name in /assets/images/Products or an absolute http(s) URL. The first image of a product, or one sent with primary set, becomes the primary image.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID" minimum(1)
+// @Param image body dto.AttachProductImageRequest true "Image data"
+// @Success 201 {object} dto.ProductImageResponse "Image attached"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Router /admin/api/products/{id}/images [post]
+func (c *ProductImageController) AttachImage(ctx *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	var req dto.AttachProductImageRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	image, err := c.imageService.AttachImage(uint(productID), req)
+	if err != nil {
+		return imageErrorResponse(ctx, err, "Failed to attach image")
+	}
+
+	return ctx.Status(201).JSON(convertImageToResponse(*image))
+}
+
+// @Summary Update product image
+// @Description Change the alt text or dimensions of a product image, or make it the primary image
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID" minimum(1)
+// @Param imageId path int true "Image ID" minimum(1)
+// @Param image body dto.UpdateProductImageRequest true "Image data"
+// @Success 200 {object} dto.ProductImageResponse "Image updated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Image not found"
+// @Router /admin/api/products/{id}/images/{imageId} [put]
+func (c *ProductImageController) UpdateImage(ctx *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	imageID, err := strconv.ParseUint(ctx.Params("imageId"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid image ID",
+		})
+	}
+
+	var req dto.UpdateProductImageRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	image, err := c.imageService.UpdateImage(uint(productID), uint(imageID), req)
+	if err != nil {
+		return imageErrorResponse(ctx, err, "Failed to update image")
+	}
+
+	return ctx.JSON(convertImageToResponse(*image))
+}
+
+// @Summary Reorder product images
+// @Description Set the display order of a product's gallery. image_ids must list every image of the product exactly once.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID" minimum(1)
+// @Param order body dto.ReorderProductImagesRequest true "Image IDs in display order"
+// @Success 200 {array} dto.ProductImageResponse "Images in their new order"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Router /admin/api/products/{id}/images/order [put]
+func (c *ProductImageController) ReorderImages(ctx *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	var req dto.ReorderProductImagesRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	images, err := c.imageService.ReorderImages(uint(productID), req.ImageIDs)
+	if err != nil {
+		return imageErrorResponse(ctx, err, "Failed to reorder images")
+	}
+
+	return ctx.JSON(convertImagesToResponses(images))
+}
+
+// @Summary Detach product image
+// @Description Remove an image from a product's gallery. When it was the primary image, the first remaining image becomes primary.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Product ID" minimum(1)
+// @Param imageId path int true "Image ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "Image detached"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Image not found"
+// @Router /admin/api/products/{id}/images/{imageId} [delete]
+func (c *ProductImageController) DetachImage(ctx *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	imageID, err := strconv.ParseUint(ctx.Params("imageId"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid image ID",
+		})
+	}
+
+	if err := c.imageService.DetachImage(uint(productID), uint(imageID)); err != nil {
+		return imageErrorResponse(ctx, err, "Failed to detach image")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Image detached successfully",
+	})
+}
+
+func imageErrorResponse(ctx *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "Product or image not found",
+		})
+	case errors.Is(err, services.ErrInvalidImageFile), errors.Is(err, services.ErrImageOrderInvalid):
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+}
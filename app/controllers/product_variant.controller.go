@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type ProductVariantController struct {
+	variantService *services.ProductVariantService
+	validate       *validator.Validate
+}
+
+func NewProductVariantController() *ProductVariantController {
+	return &ProductVariantController{
+		variantService: services.NewProductVariantService(),
+		validate:       validator.New(),
+	}
+}
+
+// @Summary Add product variant
+// @Description Add a size, colour or other variant to a product. Without a SKU one is generated from the product SKU and the variant's options; without a price the variant sells at the product price.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID" minimum(1)
+// @Param variant body dto.CreateProductVariantRequest true "Variant data"
+// @Success 201 {object} dto.ProductVariantResponse "Variant created"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 409 {object} map[string]interface{} "SKU already exists"
+// @Router /admin/api/products/{id}/variants [post]
+func (c *ProductVariantController) CreateVariant(ctx *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	var req dto.CreateProductVariantRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	product, variant, err := c.variantService.CreateVariant(uint(productID), req)
+	if err != nil {
+		return variantErrorResponse(ctx, err, "Failed to create variant")
+	}
+
+	return ctx.Status(201).JSON(convertVariantToResponse(*variant, product.Price))
+}
+
+// @Summary Update product variant
+// @Description Update the fields of a variant that are set. Send clear_price to make the variant follow the product price again.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID" minimum(1)
+// @Param variantId path int true "Variant ID" minimum(1)
+// @Param variant body dto.UpdateProductVariantRequest true "Variant data"
+// @Success 200 {object} dto.ProductVariantResponse "Variant updated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product or variant not found"
+// @Failure 409 {object} map[string]interface{} "SKU already exists"
+// @Router /admin/api/products/{id}/variants/{variantId} [put]
+func (c *ProductVariantController) UpdateVariant(ctx *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	variantID, err := strconv.ParseUint(ctx.Params("variantId"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid variant ID",
+		})
+	}
+
+	var req dto.UpdateProductVariantRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	product, variant, err := c.variantService.UpdateVariant(uint(productID), uint(variantID), req)
+	if err != nil {
+		return variantErrorResponse(ctx, err, "Failed to update variant")
+	}
+
+	return ctx.JSON(convertVariantToResponse(*variant, product.Price))
+}
+
+// @Summary Delete product variant
+// @Description Remove a variant from a product
+// @Tags admin
+// @Produce json
+// @Param id path int true "Product ID" minimum(1)
+// @Param variantId path int true "Variant ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "Variant deleted"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Variant not found"
+// @Router /admin/api/products/{id}/variants/{variantId} [delete]
+func (c *ProductVariantController) DeleteVariant(ctx *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid product ID",
+		})
+	}
+
+	variantID, err := strconv.ParseUint(ctx.Params("variantId"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid variant ID",
+		})
+	}
+
+	if err := c.variantService.DeleteVariant(uint(productID), uint(variantID)); err != nil {
+		return variantErrorResponse(ctx, err, "Failed to delete variant")
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Variant deleted successfully",
+	})
+}
+
+func variantErrorResponse(ctx *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "Product or variant not found",
+		})
+	case errors.Is(err, services.ErrVariantSKUTaken):
+		return ctx.Status(409).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type SecurityEventController struct {
+	securityEvents *services.SecurityEventService
+}
+
+func NewSecurityEventController() *SecurityEventController {
+	return &SecurityEventController{
+		securityEvents: services.NewSecurityEventService(),
+	}
+}
+
+// @Summary Query security events
+// @Description Search the security event log across all users. Times are RFC 3339; from is inclusive and to is exclusive.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(50) minimum(1) maximum(200)
+// @Param user_id query int false "Affected user ID"
+// @Param type query string false "Event type, such as login or role_change"
+// @Param outcome query string false "Outcome" Enums(success, failure)
+// @Param ip query string false "Client IP address"
+// @Param email query string false "Email address used in the attempt"
+// @Param from query string false "Only events at or after this time"
+// @Param to query string false "Only events before this time"
+// @Success 200 {object} dto.SecurityEventListResponse "Success"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Router /admin/api/security-events [get]
+func (c *SecurityEventController) GetSecurityEvents(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	filter := services.SecurityEventFilter{
+		Type:    ctx.Query("type"),
+		Outcome: ctx.Query("outcome"),
+		IP:      ctx.Query("ip"),
+		Email:   ctx.Query("email"),
+		Page:    page,
+		Limit:   limit,
+	}
+
+	switch filter.Outcome {
+	case "", models.SecurityOutcomeSuccess, models.SecurityOutcomeFailure:
+	default:
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid outcome, expected success or failure",
+		})
+	}
+
+	var err error
+	if filter.UserID, err = parseIDQuery(ctx, "user_id"); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user_id",
+		})
+	}
+	if filter.From, err = parseTimeQuery(ctx, "from"); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid from, expected an RFC 3339 time",
+		})
+	}
+	if filter.To, err = parseTimeQuery(ctx, "to"); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid to, expected an RFC 3339 time",
+		})
+	}
+
+	events, total, err := c.securityEvents.GetEvents(filter)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch security events",
+		})
+	}
+
+	return ctx.JSON(convertSecurityEventsToResponse(events, total, page, limit))
+}
+
+// newAdminSecurityEvent describes an action taken by the authenticated admin on another
+// user's account
+func newAdminSecurityEvent(ctx *fiber.Ctx, eventType string, targetID uint) models.SecurityEvent {
+	event := newSecurityEvent(ctx, eventType, models.SecurityOutcomeSuccess)
+	event.ActorID = event.UserID
+	event.UserID = &targetID
+	return event
+}
+
+// parseTimeQuery reads an optional RFC 3339 query parameter
+func parseTimeQuery(ctx *fiber.Ctx, name string) (*time.Time, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// convertSecurityEventsToResponse maps a page of security events to the list response DTO
+func convertSecurityEventsToResponse(events []models.SecurityEvent, total int64, page, limit int) dto.SecurityEventListResponse {
+	responses := make([]dto.SecurityEventResponse, len(events))
+	for i, event := range events {
+		responses[i] = dto.SecurityEventResponse{
+			ID:        event.ID,
+			Type:      event.Type,
+			Outcome:   event.Outcome,
+			UserID:    event.UserID,
+			ActorID:   event.ActorID,
+			Email:     event.Email,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			Detail:    event.Detail,
+			CreatedAt: event.CreatedAt,
+		}
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return dto.SecurityEventListResponse{
+		Events: responses,
+		Pagination: dto.PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
+	}
+}
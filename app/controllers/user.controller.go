@@ -0,0 +1,458 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+)
+
+type UserController struct {
+	authService    *services.AuthService
+	userService    *services.UserService
+	sessionService *services.SessionService
+	addressService *services.AddressService
+	securityEvents *services.SecurityEventService
+	validate       *validator.Validate
+}
+
+func NewUserController() *UserController {
+	return &UserController{
+		authService:    services.NewAuthService(),
+		userService:    services.NewUserService(),
+		sessionService: services.NewSessionService(),
+		addressService: services.NewAddressService(),
+		securityEvents: services.NewSecurityEventService(),
+		validate:       validator.New(),
+	}
+}
+
+// @Summary List users
+// @Description Get a paginated list of users, optionally filtered by search term, role and status
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1) minimum(1)
+// @Param limit query int false "Items per page" default(20) minimum(1) maximum(100)
+// @Param search query string false "Search by email or name"
+// @Param role query string false "Filter by role"
+// @Param status query string false "Filter by status" Enums(active, inactive, deleted)
+// @Success 200 {object} dto.UserListResponse "Success"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Router /admin/api/users [get]
+func (c *UserController) GetUsers(ctx *fiber.Ctx) error {
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	status := ctx.Query("status")
+	switch status {
+	case "", services.UserStatusActive, services.UserStatusInactive, services.UserStatusDeleted:
+	default:
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid status, expected active, inactive or deleted",
+		})
+	}
+
+	users, total, err := c.userService.GetUsers(services.UserFilter{
+		Search: ctx.Query("search"),
+		Role:   ctx.Query("role"),
+		Status: status,
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch users",
+		})
+	}
+
+	userResponses := make([]dto.AdminUserResponse, len(users))
+	for i, user := range users {
+		userResponses[i] = convertUserToAdminResponse(user, nil)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return ctx.JSON(dto.UserListResponse{
+		Users: userResponses,
+		Pagination: dto.PaginationInfo{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
+	})
+}
+
+// @Summary Get user
+// @Description Get a user, including soft-deleted ones, together with their profile and address book
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Success 200 {object} dto.AdminUserResponse "Success"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/users/{id} [get]
+func (c *UserController) GetUser(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	user, profile, err := c.userService.GetUserWithProfile(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
+
+	addresses, err := c.addressService.GetAddresses(user.ID)
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
+
+	response := convertUserToAdminResponse(*user, profile)
+	response.Addresses = convertAddressesToResponse(addresses)
+
+	return ctx.JSON(response)
+}
+
+// @Summary Change user role
+// @Description Assign a different role to a user. The user's live sessions are ended so the new role applies immediately.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Param role body dto.UpdateUserRoleRequest true "New role"
+// @Success 200 {object} dto.AdminUserResponse "Role changed"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/users/{id}/role [put]
+func (c *UserController) UpdateUserRole(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req dto.UpdateUserRoleRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.validate.Struct(req); err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": formatValidationError(err),
+		})
+	}
+
+	actorID, _ := ctx.Locals("user_id").(uint)
+	user, err := c.userService.ChangeRole(actorID, uint(id), req.Role)
+	if err != nil {
+		return userErrorResponse(ctx, err, "Failed to change role")
+	}
+
+	event := newAdminSecurityEvent(ctx, models.SecurityEventRoleChange, user.ID)
+	event.Detail = "new role " + user.Role
+	c.securityEvents.Record(event)
+
+	return ctx.JSON(convertUserToAdminResponse(*user, nil))
+}
+
+// @Summary Deactivate user
+// @Description Deactivate a user account and end all of its live sessions
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "Account deactivated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/users/{id}/deactivate [post]
+func (c *UserController) DeactivateUser(ctx *fiber.Ctx) error {
+	return c.setUserActive(ctx, false)
+}
+
+// @Summary Reactivate user
+// @Description Reactivate a deactivated user account
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "Account reactivated"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/users/{id}/reactivate [post]
+func (c *UserController) ReactivateUser(ctx *fiber.Ctx) error {
+	return c.setUserActive(ctx, true)
+}
+
+// @Summary Delete user
+// @Description Soft-delete a user account and end all of its live sessions. The account can be restored later.
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "User deleted"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/users/{id} [delete]
+func (c *UserController) DeleteUser(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	actorID, _ := ctx.Locals("user_id").(uint)
+	if err := c.userService.Delete(actorID, uint(id)); err != nil {
+		return userErrorResponse(ctx, err, "Failed to delete user")
+	}
+
+	c.securityEvents.Record(newAdminSecurityEvent(ctx, models.SecurityEventAccountDeleted, uint(id)))
+
+	return ctx.JSON(fiber.Map{
+		"message": "User deleted successfully",
+	})
+}
+
+// @Summary Restore user
+// @Description Restore a soft-deleted user account
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Success 200 {object} dto.AdminUserResponse "User restored"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/users/{id}/restore [post]
+func (c *UserController) RestoreUser(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	user, err := c.userService.Restore(uint(id))
+	if err != nil {
+		return userErrorResponse(ctx, err, "Failed to restore user")
+	}
+
+	return ctx.JSON(convertUserToAdminResponse(*user, nil))
+}
+
+// @Summary Unlock user account
+// @Description Lift a login lockout caused by repeated failed login attempts
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Success 200 {object} map[string]interface{} "Account unlocked"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/users/{id}/unlock [post]
+func (c *UserController) UnlockUser(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if err := c.authService.UnlockAccount(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to unlock account",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"message": "Account unlocked successfully",
+	})
+}
+
+// @Summary List user sessions
+// @Description List the devices a user is logged in on
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Success 200 {array} dto.SessionResponse "Success"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Router /admin/api/users/{id}/sessions [get]
+func (c *UserController) GetUserSessions(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	sessions, err := c.sessionService.GetSessions(uint(id))
+	if err != nil {
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to fetch sessions",
+		})
+	}
+
+	currentSessionID, _ := ctx.Locals("session_id").(string)
+	return ctx.JSON(convertSessionsToResponse(sessions, currentSessionID))
+}
+
+// @Summary Revoke user session
+// @Description Log a user out of one session
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "User ID" minimum(1)
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} map[string]interface{} "Session revoked"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /admin/api/users/{id}/sessions/{sessionId} [delete]
+func (c *UserController) RevokeUserSession(ctx *fiber.Ctx) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if err := c.sessionService.RevokeSession(uint(id), ctx.Params("sessionId")); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			return ctx.Status(404).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": "Failed to revoke session",
+		})
+	}
+
+	event := newAdminSecurityEvent(ctx, models.SecurityEventSessionRevoked, uint(id))
+	event.Detail = "session " + ctx.Params("sessionId")
+	c.securityEvents.Record(event)
+
+	return ctx.JSON(fiber.Map{
+		"message": "Session revoked successfully",
+	})
+}
+
+func (c *UserController) setUserActive(ctx *fiber.Ctx, active bool) error {
+	id, err := strconv.ParseUint(ctx.Params("id"), 10, 32)
+	if err != nil {
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	actorID, _ := ctx.Locals("user_id").(uint)
+	if err := c.userService.SetActive(actorID, uint(id), active); err != nil {
+		return userErrorResponse(ctx, err, "Failed to update account status")
+	}
+
+	eventType := models.SecurityEventAccountDisabled
+	message := "Account deactivated successfully"
+	if active {
+		eventType = models.SecurityEventAccountEnabled
+		message = "Account reactivated successfully"
+	}
+	c.securityEvents.Record(newAdminSecurityEvent(ctx, eventType, uint(id)))
+
+	return ctx.JSON(fiber.Map{
+		"message": message,
+	})
+}
+
+// userErrorResponse maps user administration errors to HTTP responses
+func userErrorResponse(ctx *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ctx.Status(404).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case errors.Is(err, services.ErrCannotModifySelf),
+		errors.Is(err, services.ErrUnknownRole),
+		errors.Is(err, services.ErrUserNotDeleted):
+		return ctx.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return ctx.Status(500).JSON(fiber.Map{
+			"error": fallback,
+		})
+	}
+}
+
+func convertUserToAdminResponse(user models.User, profile *models.UserProfile) dto.AdminUserResponse {
+	response := dto.AdminUserResponse{
+		ID:             user.ID,
+		Email:          user.Email,
+		FirstName:      user.FirstName,
+		LastName:       user.LastName,
+		Role:           user.Role,
+		Active:         user.Active,
+		EmailVerified:  user.EmailVerified,
+		MFAEnabled:     user.MFAEnabled,
+		ServiceAccount: user.ServiceAccount,
+		CreatedAt:      user.CreatedAt,
+	}
+
+	if user.DeletedAt.Valid {
+		deletedAt := user.DeletedAt.Time
+		response.DeletedAt = &deletedAt
+	}
+
+	if profile != nil {
+		response.Profile = &dto.UserProfileResponse{
+			Phone: profile.Phone,
+		}
+	}
+
+	return response
+}
@@ -1,25 +1,30 @@
 package dto
 
+import "time"
+
 // CreateProductRequest represents the request to create a new product
 type CreateProductRequest struct {
-	Name             string  `json:"name" validate:"required"`
-	Description      string  `json:"description"`
-	ShortDescription string  `json:"short_description"`
-	Brand            string  `json:"brand"`
-	Category         string  `json:"category"`
-	Price            float64 `json:"price" validate:"required,gt=0"`
-	Currency         string  `json:"currency"`
-	Stock            int     `json:"stock" validate:"gte=0"`
-	EAN              string  `json:"ean"`
-	Color            string  `json:"color"`
-	Size             string  `json:"size"`
-	Availability     string  `json:"availability"`
-	Image            string  `json:"image"`
-	InternalID       string  `json:"internal_id"`
-	Slug             string  `json:"slug"`
-	SKU              string  `json:"sku"`
-	CategoryID       uint    `json:"category_id" validate:"required"`
-	Active           bool    `json:"active"`
+	Name             string                        `json:"name" validate:"required"`
+	Description      string                        `json:"description"`
+	ShortDescription string                        `json:"short_description"`
+	Brand            string                        `json:"brand"`
+	Category         string                        `json:"category"`
+	Price            float64                       `json:"price" validate:"required,gt=0"`
+	Currency         string                        `json:"currency"`
+	Stock            int                           `json:"stock" validate:"gte=0"`
+	EAN              string                        `json:"ean"`
+	Color            string                        `json:"color"`
+	Size             string                        `json:"size"`
+	Availability     string                        `json:"availability"`
+	Image            string                        `json:"image"`
+	InternalID       string                        `json:"internal_id"`
+	Slug             string                        `json:"slug"`
+	SKU              string                        `json:"sku"`
+	CategoryID       uint                          `json:"category_id" validate:"required"`
+	GroupKey         string                        `json:"group_key"`
+	Active           bool                          `json:"active"`
+	Variants         []CreateProductVariantRequest `json:"variants" validate:"dive"`
+	Attributes       map[string]interface{}        `json:"attributes"`
 }
 
 // UpdateProductRequest represents the request to update an existing product
@@ -41,7 +46,134 @@ type UpdateProductRequest struct {
 	Slug             *string  `json:"slug"`
 	SKU              *string  `json:"sku"`
 	CategoryID       *uint    `json:"category_id"`
+	GroupKey         *string  `json:"group_key"`
 	Active           *bool    `json:"active"`
+	// Attributes sets the listed attributes by key; a null value removes one
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// CreateProductVariantRequest adds a variant to a product. A SKU is generated from the
+// parent's SKU when none is given; without a price the variant sells at the parent price.
+type CreateProductVariantRequest struct {
+	SKU      string   `json:"sku" validate:"omitempty,max=64"`
+	EAN      string   `json:"ean"`
+	Color    string   `json:"color"`
+	Size     string   `json:"size"`
+	Price    *float64 `json:"price" validate:"omitempty,gt=0"`
+	Stock    int      `json:"stock" validate:"gte=0"`
+	Image    string   `json:"image"`
+	Position int      `json:"position"`
+}
+
+// UpdateProductVariantRequest changes the fields that are set. ClearPrice removes the price
+// override so the variant follows the parent price again.
+type UpdateProductVariantRequest struct {
+	SKU        *string  `json:"sku" validate:"omitempty,min=1,max=64"`
+	EAN        *string  `json:"ean"`
+	Color      *string  `json:"color"`
+	Size       *string  `json:"size"`
+	Price      *float64 `json:"price" validate:"omitempty,gt=0"`
+	ClearPrice bool     `json:"clear_price"`
+	Stock      *int     `json:"stock" validate:"omitempty,gte=0"`
+	Image      *string  `json:"image"`
+	Position   *int     `json:"position"`
+	Active     *bool    `json:"active"`
+}
+
+// AttachProductImageRequest adds an image to a product gallery. File is a file name under
+// /assets/images/Products or an absolute http(s) URL. The first image of a product is always primary.
+type AttachProductImageRequest struct {
+	File    string `json:"file" validate:"required,max=500"`
+	AltText string `json:"alt_text" validate:"max=255"`
+	Primary bool   `json:"primary"`
+	Width   int    `json:"width" validate:"gte=0"`
+	Height  int    `json:"height" validate:"gte=0"`
+}
+
+// UpdateProductImageRequest changes the fields of a product image that are set. Setting primary
+// to true makes the image the product's primary image.
+type UpdateProductImageRequest struct {
+	AltText *string `json:"alt_text" validate:"omitempty,max=255"`
+	Primary bool    `json:"primary"`
+	Width   *int    `json:"width" validate:"omitempty,gte=0"`
+	Height  *int    `json:"height" validate:"omitempty,gte=0"`
+}
+
+// ReorderProductImagesRequest lists every image ID of a product in the new display order
+type ReorderProductImagesRequest struct {
+	ImageIDs []uint `json:"image_ids" validate:"required,min=1"`
+}
+
+// CreateAttributeDefinitionRequest adds an attribute to a category. The key is derived from the
+// name when it is not given. Enum attributes need at least one option.
+type CreateAttributeDefinitionRequest struct {
+	Key      string   `json:"key" validate:"omitempty,max=64"`
+	Name     string   `json:"name" validate:"required,max=100"`
+	Type     string   `json:"type" validate:"required,oneof=text integer decimal boolean enum"`
+	Unit     string   `json:"unit" validate:"max=20"`
+	Options  []string `json:"options" validate:"dive,required,max=100"`
+	Required bool     `json:"required"`
+	Position int      `json:"position"`
+}
+
+// UpdateAttributeDefinitionRequest changes the fields of an attribute that are set. The key and
+// type cannot change, because stored values depend on them.
+type UpdateAttributeDefinitionRequest struct {
+	Name     *string  `json:"name" validate:"omitempty,min=1,max=100"`
+	Unit     *string  `json:"unit" validate:"omitempty,max=20"`
+	Options  []string `json:"options" validate:"omitempty,dive,required,max=100"`
+	Required *bool    `json:"required"`
+	Position *int     `json:"position"`
+}
+
+// CreateCategoryRequest adds a category, at the root of the tree when ParentID is not set
+type CreateCategoryRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
+	Active      *bool  `json:"active"`
+}
+
+// UpdateCategoryRequest changes the fields of a category that are set. A parent_id of 0 moves
+// the category to the root of the tree.
+type UpdateCategoryRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description"`
+	ParentID    *uint   `json:"parent_id"`
+}
+
+// MergeCategoryRequest names the category that takes over the products and subcategories
+type MergeCategoryRequest struct {
+	TargetID uint `json:"target_id" validate:"required"`
+}
+
+// CategoryMergeResponse is the target category after a merge and what was moved into it
+type CategoryMergeResponse struct {
+	Category           CategoryResponse `json:"category"`
+	ProductsMoved      int64            `json:"products_moved"`
+	SubcategoriesMoved int64            `json:"subcategories_moved"`
+}
+
+// CreateBrandRequest adds a brand. The logo is a file name in /assets/images/Brands or an
+// absolute http(s) URL.
+type CreateBrandRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Logo        string `json:"logo" validate:"max=500"`
+	Description string `json:"description"`
+	Active      *bool  `json:"active"`
+}
+
+// UpdateBrandRequest changes the fields of a brand that are set
+type UpdateBrandRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Logo        *string `json:"logo" validate:"omitempty,max=500"`
+	Description *string `json:"description"`
+	Active      *bool   `json:"active"`
+}
+
+// AddBrandAliasRequest adds another spelling of a brand name
+type AddBrandAliasRequest struct {
+	Alias string `json:"alias" validate:"required,max=100"`
 }
 
 // BulkUploadResult represents the result of a bulk upload operation
@@ -60,3 +192,167 @@ type AdminStats struct {
 	AveragePrice     float64 `json:"average_price"`
 	LowStockProducts int64   `json:"low_stock_products"`
 }
+
+// CreateRoleRequest represents the request to create a new role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRolePermissionsRequest replaces the permissions granted to a role
+type UpdateRolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+// RoleResponse represents a role with its granted permissions
+type RoleResponse struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// PermissionResponse represents a permission that can be granted to roles
+type PermissionResponse struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// CreateServiceAccountRequest represents the request to create a service account
+type CreateServiceAccountRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+	Role string `json:"role" validate:"required"`
+}
+
+// ServiceAccountResponse represents a service account
+type ServiceAccountResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateAPIKeyRequest represents the request to issue an API key to a service account
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,min=2,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse represents an API key. Key is only set in the response to its creation.
+type APIKeyResponse struct {
+	ID               uint       `json:"id"`
+	ServiceAccountID uint       `json:"service_account_id"`
+	Name             string     `json:"name"`
+	Prefix           string     `json:"prefix"`
+	Key              string     `json:"key,omitempty"`
+	Scopes           []string   `json:"scopes"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// AdminUserResponse represents a user account as seen by administrators
+type AdminUserResponse struct {
+	ID             uint                 `json:"id"`
+	Email          string               `json:"email"`
+	FirstName      string               `json:"first_name"`
+	LastName       string               `json:"last_name"`
+	Role           string               `json:"role"`
+	Active         bool                 `json:"active"`
+	EmailVerified  bool                 `json:"email_verified"`
+	MFAEnabled     bool                 `json:"mfa_enabled"`
+	ServiceAccount bool                 `json:"service_account"`
+	CreatedAt      time.Time            `json:"created_at"`
+	DeletedAt      *time.Time           `json:"deleted_at,omitempty"`
+	Profile        *UserProfileResponse `json:"profile,omitempty"`
+	Addresses      []AddressResponse    `json:"addresses,omitempty"`
+}
+
+// UserProfileResponse represents the contact details stored in a user's profile
+type UserProfileResponse struct {
+	Phone string `json:"phone"`
+}
+
+// UserListResponse represents a page of users
+type UserListResponse struct {
+	Users      []AdminUserResponse `json:"users"`
+	Pagination PaginationInfo      `json:"pagination"`
+}
+
+// UpdateUserRoleRequest represents the request to change a user's role
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// SecurityEventResponse represents one entry of the security event log
+type SecurityEventResponse struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	Outcome   string    `json:"outcome"`
+	UserID    *uint     `json:"user_id"`
+	ActorID   *uint     `json:"actor_id,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SecurityEventListResponse represents a page of security events
+type SecurityEventListResponse struct {
+	Events     []SecurityEventResponse `json:"events"`
+	Pagination PaginationInfo          `json:"pagination"`
+}
+
+// AdminSessionResponse describes the signed-in dashboard user. The CSRF token must be sent in
+// the X-CSRF-Token header with every state-changing /admin/api request.
+type AdminSessionResponse struct {
+	User      UserResponse `json:"user"`
+	CSRFToken string       `json:"csrf_token"`
+	MFA       bool         `json:"mfa"`
+	ExpiresIn int64        `json:"expires_in,omitempty"`
+}
+
+// ImpersonateRequest represents the request to act as a user
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ImpersonationSessionResponse represents one recorded impersonation session
+type ImpersonationSessionResponse struct {
+	ID         uint       `json:"id"`
+	SessionID  string     `json:"session_id"`
+	AdminID    uint       `json:"admin_id"`
+	AdminEmail string     `json:"admin_email,omitempty"`
+	UserID     uint       `json:"user_id"`
+	UserEmail  string     `json:"user_email,omitempty"`
+	Reason     string     `json:"reason"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	StartedAt  time.Time  `json:"started_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	Active     bool       `json:"active"`
+}
+
+// ImpersonationResponse holds the access token for acting as a user. The token cannot be
+// refreshed and every response made with it carries the X-Impersonated-By header.
+type ImpersonationResponse struct {
+	Impersonation bool                         `json:"impersonation"`
+	Token         string                       `json:"token"`
+	ExpiresIn     int64                        `json:"expires_in"`
+	User          UserResponse                 `json:"user"`
+	Session       ImpersonationSessionResponse `json:"session"`
+}
+
+// ImpersonationListResponse represents a page of impersonation sessions
+type ImpersonationListResponse struct {
+	Sessions   []ImpersonationSessionResponse `json:"sessions"`
+	Pagination PaginationInfo                 `json:"pagination"`
+}
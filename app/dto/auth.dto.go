@@ -1,8 +1,10 @@
 package dto
 
+import "time"
+
 type RegisterRequest struct {
 	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=6"`
+	Password  string `json:"password" validate:"required"`
 	FirstName string `json:"first_name" validate:"required"`
 	LastName  string `json:"last_name" validate:"required"`
 }
@@ -12,25 +14,152 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+	Reason   string `json:"reason" validate:"max=1000"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+type MFAEnrolmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token        string       `json:"token,omitempty"`
+	RefreshToken string       `json:"refresh_token,omitempty"`
+	ExpiresIn    int64        `json:"expires_in,omitempty"`
+	User         UserResponse `json:"user"`
+	Message      string       `json:"message,omitempty"`
 }
 
 type UserResponse struct {
-	ID        uint   `json:"id"`
-	Email     string `json:"email"`
+	ID            uint   `json:"id"`
+	Email         string `json:"email"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	MFAEnabled    bool   `json:"mfa_enabled"`
+}
+
+type UpdateProfileRequest struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
-	Role      string `json:"role"`
+	Phone     string `json:"phone"`
 }
 
-type UpdateProfileRequest struct {
-	FirstName  string `json:"first_name"`
-	LastName   string `json:"last_name"`
-	Phone      string `json:"phone"`
-	Address    string `json:"address"`
-	City       string `json:"city"`
-	Country    string `json:"country"`
-	PostalCode string `json:"postal_code"`
+// AddressRequest represents a new address book entry. Country is an ISO 3166-1 alpha-2 code.
+type AddressRequest struct {
+	Label           string `json:"label" validate:"required,max=50"`
+	RecipientName   string `json:"recipient_name" validate:"max=200"`
+	Line1           string `json:"line1" validate:"required,max=200"`
+	Line2           string `json:"line2" validate:"max=200"`
+	City            string `json:"city" validate:"required,max=100"`
+	Region          string `json:"region" validate:"max=100"`
+	PostalCode      string `json:"postal_code" validate:"max=20"`
+	Country         string `json:"country" validate:"required,len=2"`
+	Phone           string `json:"phone" validate:"max=30"`
+	DefaultShipping bool   `json:"default_shipping"`
+	DefaultBilling  bool   `json:"default_billing"`
+}
+
+// UpdateAddressRequest represents a partial update of an address book entry
+type UpdateAddressRequest struct {
+	Label           *string `json:"label" validate:"omitempty,max=50"`
+	RecipientName   *string `json:"recipient_name" validate:"omitempty,max=200"`
+	Line1           *string `json:"line1" validate:"omitempty,max=200"`
+	Line2           *string `json:"line2" validate:"omitempty,max=200"`
+	City            *string `json:"city" validate:"omitempty,max=100"`
+	Region          *string `json:"region" validate:"omitempty,max=100"`
+	PostalCode      *string `json:"postal_code" validate:"omitempty,max=20"`
+	Country         *string `json:"country" validate:"omitempty,len=2"`
+	Phone           *string `json:"phone" validate:"omitempty,max=30"`
+	DefaultShipping *bool   `json:"default_shipping"`
+	DefaultBilling  *bool   `json:"default_billing"`
+}
+
+type AddressResponse struct {
+	ID              uint      `json:"id"`
+	Label           string    `json:"label"`
+	RecipientName   string    `json:"recipient_name"`
+	Line1           string    `json:"line1"`
+	Line2           string    `json:"line2"`
+	City            string    `json:"city"`
+	Region          string    `json:"region"`
+	PostalCode      string    `json:"postal_code"`
+	Country         string    `json:"country"`
+	CountryName     string    `json:"country_name"`
+	Phone           string    `json:"phone"`
+	DefaultShipping bool      `json:"default_shipping"`
+	DefaultBilling  bool      `json:"default_billing"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	MFA        bool      `json:"mfa"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+	// ImpersonatedBy is the ID of the admin acting as the user in this session
+	ImpersonatedBy *uint `json:"impersonated_by,omitempty"`
+}
+
+type AccountDeletionResponse struct {
+	Method       string    `json:"method"`
+	RequestedAt  time.Time `json:"requested_at"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Message      string    `json:"message"`
 }
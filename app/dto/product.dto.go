@@ -1,37 +1,133 @@
 package dto
 
 type ProductResponse struct {
-	ID               uint             `json:"id"`
-	Index            int              `json:"index"`
-	Name             string           `json:"name"`
-	Description      string           `json:"description"`
-	ShortDescription string           `json:"short_description"`
-	Brand            string           `json:"brand"`
-	Category         string           `json:"category"`
-	Price            float64          `json:"price"`
-	Currency         string           `json:"currency"`
-	Stock            int              `json:"stock"`
-	EAN              string           `json:"ean"`
-	Color            string           `json:"color"`
-	Size             string           `json:"size"`
-	Availability     string           `json:"availability"`
-	Image            string           `json:"image"`
-	ImageURL         string           `json:"image_url"`
-	InternalID       string           `json:"internal_id"`
-	Slug             string           `json:"slug"`
-	SKU              string           `json:"sku"`
-	CategoryModel    CategoryResponse `json:"category_model,omitempty"`
-	Active           bool             `json:"active"`
-	CreatedAt        string           `json:"created_at"`
-	UpdatedAt        string           `json:"updated_at"`
+	ID               uint                       `json:"id"`
+	Index            int                        `json:"index"`
+	Name             string                     `json:"name"`
+	Description      string                     `json:"description"`
+	ShortDescription string                     `json:"short_description"`
+	Brand            string                     `json:"brand"`
+	BrandID          *uint                      `json:"brand_id"`
+	BrandModel       *BrandResponse             `json:"brand_model,omitempty"`
+	Category         string                     `json:"category"`
+	Price            float64                    `json:"price"`
+	Currency         string                     `json:"currency"`
+	Stock            int                        `json:"stock"`
+	EAN              string                     `json:"ean"`
+	Color            string                     `json:"color"`
+	Size             string                     `json:"size"`
+	Availability     string                     `json:"availability"`
+	Image            string                     `json:"image"`
+	ImageURL         string                     `json:"image_url"`
+	InternalID       string                     `json:"internal_id"`
+	Slug             string                     `json:"slug"`
+	SKU              string                     `json:"sku"`
+	GroupKey         string                     `json:"group_key,omitempty"`
+	Variants         []ProductVariantResponse   `json:"variants"`
+	Images           []ProductImageResponse     `json:"images"`
+	Attributes       []ProductAttributeResponse `json:"attributes"`
+	CategoryModel    CategoryResponse           `json:"category_model,omitempty"`
+	Breadcrumbs      []CategoryBreadcrumb       `json:"breadcrumbs"`
+	Active           bool                       `json:"active"`
+	CreatedAt        string                     `json:"created_at"`
+	UpdatedAt        string                     `json:"updated_at"`
+}
+
+// ProductVariantResponse is a variant of a product. Price is the price the variant sells at;
+// PriceOverride is only set when it differs from the parent product.
+type ProductVariantResponse struct {
+	ID            uint     `json:"id"`
+	SKU           string   `json:"sku"`
+	EAN           string   `json:"ean"`
+	Color         string   `json:"color"`
+	Size          string   `json:"size"`
+	Price         float64  `json:"price"`
+	PriceOverride *float64 `json:"price_override,omitempty"`
+	Stock         int      `json:"stock"`
+	Image         string   `json:"image"`
+	ImageURL      string   `json:"image_url"`
+	Position      int      `json:"position"`
+	Active        bool     `json:"active"`
+}
+
+// ProductImageResponse is one image of a product gallery, in display order
+type ProductImageResponse struct {
+	ID       uint   `json:"id"`
+	File     string `json:"file"`
+	URL      string `json:"url"`
+	AltText  string `json:"alt_text"`
+	Position int    `json:"position"`
+	Primary  bool   `json:"primary"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// ProductAttributeResponse is a typed specification of a product. Value is a number, boolean or
+// string depending on Type.
+type ProductAttributeResponse struct {
+	Key   string      `json:"key"`
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Unit  string      `json:"unit,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+// AttributeDefinitionResponse describes an attribute that products of a category can have
+type AttributeDefinitionResponse struct {
+	ID         uint     `json:"id"`
+	CategoryID uint     `json:"category_id"`
+	Key        string   `json:"key"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Unit       string   `json:"unit,omitempty"`
+	Options    []string `json:"options,omitempty"`
+	Required   bool     `json:"required"`
+	Position   int      `json:"position"`
 }
 
 type CategoryResponse struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Slug        string `json:"slug"`
-	Active      bool   `json:"active"`
+	ID           uint   `json:"id"`
+	ParentID     *uint  `json:"parent_id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Slug         string `json:"slug"`
+	Active       bool   `json:"active"`
+	ProductCount int64  `json:"product_count"`
+}
+
+type BrandResponse struct {
+	ID           uint                 `json:"id"`
+	Name         string               `json:"name"`
+	Slug         string               `json:"slug"`
+	Logo         string               `json:"logo"`
+	LogoURL      string               `json:"logo_url"`
+	Description  string               `json:"description"`
+	Active       bool                 `json:"active"`
+	ProductCount int64                `json:"product_count"`
+	Aliases      []BrandAliasResponse `json:"aliases,omitempty"`
+}
+
+// BrandAliasResponse is a spelling of a brand name that maps to the brand
+type BrandAliasResponse struct {
+	ID    uint   `json:"id"`
+	Alias string `json:"alias"`
+}
+
+// CategoryBreadcrumb is one level of the path from the root category down to a product's category
+type CategoryBreadcrumb struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// CategoryTreeResponse is a category with its subcategories
+type CategoryTreeResponse struct {
+	ID           uint                   `json:"id"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Slug         string                 `json:"slug"`
+	ProductCount int64                  `json:"product_count"`
+	Children     []CategoryTreeResponse `json:"children"`
 }
 
 type ProductListResponse struct {
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// Account deletion methods
+const (
+	AccountDeletionAnonymise = "anonymise"
+	AccountDeletionDelete    = "delete"
+)
+
+// AccountDeletion records a deletion request for compliance. It outlives the user, so it
+// only keeps a hash of the email address.
+type AccountDeletion struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	EmailHash    string     `json:"email_hash" gorm:"not null;index"`
+	Method       string     `json:"method" gorm:"not null"`
+	Reason       string     `json:"reason"`
+	RequestedAt  time.Time  `json:"requested_at" gorm:"not null"`
+	ScheduledFor time.Time  `json:"scheduled_for" gorm:"not null;index"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CancelledAt  *time.Time `json:"cancelled_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
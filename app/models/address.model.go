@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Address is an entry in a user's address book. Country is an ISO 3166-1 alpha-2 code; the
+// other personal fields are encrypted at rest.
+type Address struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	UserID          uint           `json:"user_id" gorm:"not null;index"`
+	Label           string         `json:"label" gorm:"not null"`
+	RecipientName   string         `json:"recipient_name" gorm:"serializer:encrypted"`
+	Line1           string         `json:"line1" gorm:"not null;serializer:encrypted"`
+	Line2           string         `json:"line2" gorm:"serializer:encrypted"`
+	City            string         `json:"city" gorm:"not null;serializer:encrypted"`
+	Region          string         `json:"region" gorm:"serializer:encrypted"`
+	PostalCode      string         `json:"postal_code" gorm:"serializer:encrypted"`
+	Country         string         `json:"country" gorm:"not null"`
+	Phone           string         `json:"phone" gorm:"serializer:encrypted"`
+	DefaultShipping bool           `json:"default_shipping" gorm:"default:false"`
+	DefaultBilling  bool           `json:"default_billing" gorm:"default:false"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+}
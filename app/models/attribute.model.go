@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+)
+
+// Attribute types
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeInteger = "integer"
+	AttributeTypeDecimal = "decimal"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeEnum    = "enum"
+)
+
+// AttributeDefinition describes a specification that products of a category can have, such as
+// "RAM" as an integer in GB or "Material" chosen from a list. Key is the stable name used by the
+// API, bulk uploads and search filters.
+type AttributeDefinition struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CategoryID uint      `json:"category_id" gorm:"not null;uniqueIndex:idx_attribute_definitions_category_key"`
+	Category   Category  `json:"-" gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE"`
+	Key        string    `json:"key" gorm:"not null;uniqueIndex:idx_attribute_definitions_category_key"`
+	Name       string    `json:"name" gorm:"not null"`
+	Type       string    `json:"type" gorm:"not null"`
+	Unit       string    `json:"unit"`
+	Options    []string  `json:"options,omitempty" gorm:"serializer:json"`
+	Required   bool      `json:"required" gorm:"not null;default:false"`
+	Position   int       `json:"position" gorm:"not null;default:0"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Numeric reports whether values of the attribute are stored as numbers
+func (d AttributeDefinition) Numeric() bool {
+	return d.Type == AttributeTypeInteger || d.Type == AttributeTypeDecimal
+}
+
+// ProductAttributeValue is the value of one attribute for a product. Only the column that
+// matches the attribute's type is set, so numbers and booleans can be filtered as such.
+type ProductAttributeValue struct {
+	ID          uint                `json:"id" gorm:"primaryKey"`
+	ProductID   uint                `json:"product_id" gorm:"not null;uniqueIndex:idx_product_attribute_values_product_attribute"`
+	AttributeID uint                `json:"attribute_id" gorm:"not null;index;uniqueIndex:idx_product_attribute_values_product_attribute"`
+	Attribute   AttributeDefinition `json:"attribute" gorm:"foreignKey:AttributeID;constraint:OnDelete:CASCADE"`
+	ValueText   string              `json:"value_text,omitempty" gorm:"index"`
+	ValueNumber *float64            `json:"value_number,omitempty" gorm:"index"`
+	ValueBool   *bool               `json:"value_bool,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// Value returns the typed value of the attribute
+func (v ProductAttributeValue) Value() interface{} {
+	switch v.Attribute.Type {
+	case AttributeTypeInteger:
+		if v.ValueNumber != nil {
+			return int64(*v.ValueNumber)
+		}
+	case AttributeTypeDecimal:
+		if v.ValueNumber != nil {
+			return *v.ValueNumber
+		}
+	case AttributeTypeBoolean:
+		if v.ValueBool != nil {
+			return *v.ValueBool
+		}
+	default:
+		return v.ValueText
+	}
+	return nil
+}
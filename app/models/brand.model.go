@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Brand is a product brand. Products keep the brand name in Product.Brand and point at the
+// brand with BrandID. ProductCount is not stored; listings fill it with the number of active
+// products of the brand.
+type Brand struct {
+	ID           uint         `json:"id" gorm:"primaryKey"`
+	Name         string       `json:"name" gorm:"not null;uniqueIndex"`
+	Slug         string       `json:"slug" gorm:"not null;uniqueIndex"`
+	Logo         string       `json:"logo"`
+	Description  string       `json:"description"`
+	Active       bool         `json:"active" gorm:"default:true"`
+	Aliases      []BrandAlias `json:"aliases,omitempty" gorm:"foreignKey:BrandID;constraint:OnDelete:CASCADE"`
+	ProductCount int64        `json:"product_count" gorm:"->;-:migration"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// BrandAlias maps a spelling of a brand name, such as "Apple Inc.", to its brand. Key is the
+// normalised form of the spelling, so a new spelling with a known key finds the same brand.
+type BrandAlias struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BrandID   uint      `json:"brand_id" gorm:"not null;index"`
+	Alias     string    `json:"alias" gorm:"not null;uniqueIndex"`
+	Key       string    `json:"key" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// ImpersonationSession records an admin acting as another user. SessionID is the token family
+// of the impersonation token, so the session can be revoked like any other.
+type ImpersonationSession struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	AdminID   uint       `json:"admin_id" gorm:"not null;index"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	SessionID string     `json:"session_id" gorm:"not null;uniqueIndex"`
+	Reason    string     `json:"reason" gorm:"not null"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+	StartedAt time.Time  `json:"started_at" gorm:"not null;index"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	EndedAt   *time.Time `json:"ended_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Active reports whether the impersonation token can still be used
+func (s ImpersonationSession) Active() bool {
+	return s.EndedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -6,41 +6,115 @@ import (
 	"gorm.io/gorm"
 )
 
+// Category is a node in the category tree. Names are unique among the children of one parent.
+// ProductCount is not stored; listings fill it with the number of active products in the category.
 type Category struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"not null;uniqueIndex"`
-	Description string         `json:"description"`
-	Slug        string         `json:"slug" gorm:"uniqueIndex;not null"`
-	Active      bool           `json:"active" gorm:"default:true"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
-	Products    []Product      `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ParentID     *uint          `json:"parent_id" gorm:"index"`
+	Parent       *Category      `json:"-" gorm:"foreignKey:ParentID"`
+	Name         string         `json:"name" gorm:"not null;index"`
+	Description  string         `json:"description"`
+	Slug         string         `json:"slug" gorm:"uniqueIndex;not null"`
+	Active       bool           `json:"active" gorm:"default:true"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	Products     []Product      `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
+	Children     []Category     `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+	ProductCount int64          `json:"product_count" gorm:"->;-:migration"`
 }
 
 type Product struct {
-	ID               uint           `json:"id" gorm:"primaryKey"`
-	Index            int            `json:"index" gorm:"index"`
-	Name             string         `json:"name" gorm:"not null;index"`
-	Description      string         `json:"description"`
-	ShortDescription string         `json:"short_description"`
-	Brand            string         `json:"brand" gorm:"index"`
-	Category         string         `json:"category" gorm:"index"`
-	Price            float64        `json:"price" gorm:"not null;index"`
-	Currency         string         `json:"currency" gorm:"default:'USD'"`
-	Stock            int            `json:"stock" gorm:"not null;default:0"`
-	EAN              string         `json:"ean" gorm:"index"`
-	Color            string         `json:"color"`
-	Size             string         `json:"size"`
-	Availability     string         `json:"availability" gorm:"index"`
-	Image            string         `json:"image"`
-	InternalID       string         `json:"internal_id" gorm:"index"`
-	Slug             string         `json:"slug" gorm:"not null;index"`
-	SKU              string         `json:"sku" gorm:"not null;index"`
-	CategoryID       uint           `json:"category_id" gorm:"index"`
-	CategoryModel    Category       `json:"category_model,omitempty" gorm:"foreignKey:CategoryID"`
-	Active           bool           `json:"active" gorm:"default:true"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uint                    `json:"id" gorm:"primaryKey"`
+	Index            int                     `json:"index" gorm:"index"`
+	Name             string                  `json:"name" gorm:"not null;index"`
+	Description      string                  `json:"description"`
+	ShortDescription string                  `json:"short_description"`
+	Brand            string                  `json:"brand" gorm:"index"`
+	BrandID          *uint                   `json:"brand_id" gorm:"index"`
+	BrandModel       *Brand                  `json:"brand_model,omitempty" gorm:"foreignKey:BrandID"`
+	Category         string                  `json:"category" gorm:"index"`
+	Price            float64                 `json:"price" gorm:"not null;index"`
+	Currency         string                  `json:"currency" gorm:"default:'USD'"`
+	Stock            int                     `json:"stock" gorm:"not null;default:0"`
+	EAN              string                  `json:"ean" gorm:"index"`
+	Color            string                  `json:"color"`
+	Size             string                  `json:"size"`
+	Availability     string                  `json:"availability" gorm:"index"`
+	Image            string                  `json:"image"`
+	InternalID       string                  `json:"internal_id" gorm:"index"`
+	Slug             string                  `json:"slug" gorm:"not null;index"`
+	SKU              string                  `json:"sku" gorm:"not null;index"`
+	GroupKey         string                  `json:"group_key,omitempty" gorm:"index"`
+	CategoryID       uint                    `json:"category_id" gorm:"index"`
+	CategoryModel    Category                `json:"category_model,omitempty" gorm:"foreignKey:CategoryID"`
+	Breadcrumbs      []Category              `json:"breadcrumbs,omitempty" gorm:"-"`
+	Variants         []ProductVariant        `json:"variants,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Images           []ProductImage          `json:"images,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Attributes       []ProductAttributeValue `json:"attributes,omitempty" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	Active           bool                    `json:"active" gorm:"default:true"`
+	CreatedAt        time.Time               `json:"created_at"`
+	UpdatedAt        time.Time               `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt          `json:"-" gorm:"index"`
+}
+
+// AfterCreate adds the product's image to its gallery as the primary image
+func (p *Product) AfterCreate(tx *gorm.DB) error {
+	if p.Image == "" || len(p.Images) > 0 {
+		return nil
+	}
+
+	image := ProductImage{
+		ProductID: p.ID,
+		File:      p.Image,
+		AltText:   p.Name,
+		IsPrimary: true,
+	}
+	if err := tx.Create(&image).Error; err != nil {
+		return err
+	}
+	p.Images = []ProductImage{image}
+	return nil
+}
+
+// ProductImage is one picture in a product's gallery. File is a file name under
+// /assets/images/Products or an absolute URL. The file of the primary image is also kept in
+// Product.Image for clients that only show one picture.
+type ProductImage struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProductID uint      `json:"product_id" gorm:"not null;index"`
+	File      string    `json:"file" gorm:"not null"`
+	AltText   string    `json:"alt_text"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	IsPrimary bool      `json:"primary" gorm:"column:is_primary;not null;default:false"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductVariant is one sellable version of a product, such as a size or colour. Price is an
+// optional override; variants without one are sold at the parent product's price.
+type ProductVariant struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProductID uint      `json:"product_id" gorm:"not null;index"`
+	SKU       string    `json:"sku" gorm:"not null;uniqueIndex"`
+	EAN       string    `json:"ean" gorm:"index"`
+	Color     string    `json:"color"`
+	Size      string    `json:"size"`
+	Price     *float64  `json:"price,omitempty"`
+	Stock     int       `json:"stock" gorm:"not null;default:0"`
+	Image     string    `json:"image"`
+	Position  int       `json:"position" gorm:"not null;default:0"`
+	Active    bool      `json:"active" gorm:"default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EffectivePrice returns the variant's own price, or the parent price when it has none
+func (v ProductVariant) EffectivePrice(parentPrice float64) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return parentPrice
 }
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"not null;uniqueIndex"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions;"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type Permission struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;uniqueIndex"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Built-in roles
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Permissions checked by the authorization middleware
+const (
+	PermissionProductsRead    = "products:read"
+	PermissionProductsWrite   = "products:write"
+	PermissionProductsDelete  = "products:delete"
+	PermissionCategoriesRead  = "categories:read"
+	PermissionCategoriesWrite = "categories:write"
+	PermissionCacheClear      = "cache:clear"
+	PermissionSeedWrite       = "seed:write"
+	PermissionStatisticsRead  = "statistics:read"
+	PermissionRolesManage     = "roles:manage"
+	PermissionUsersManage     = "users:manage"
+	PermissionAPIKeysManage   = "api_keys:manage"
+	PermissionSecurityRead    = "security_events:read"
+	PermissionImpersonate     = "users:impersonate"
+)
+
+// DefaultPermissions lists every built-in permission with its description
+var DefaultPermissions = map[string]string{
+	PermissionProductsRead:    "View products in the admin API",
+	PermissionProductsWrite:   "Create, update and bulk upload products and manage brands",
+	PermissionProductsDelete:  "Delete single products or the whole catalog",
+	PermissionCategoriesRead:  "View categories in the admin API",
+	PermissionCategoriesWrite: "Manage categories and their product attributes",
+	PermissionCacheClear:      "Clear application caches",
+	PermissionSeedWrite:       "Seed or clear catalog data",
+	PermissionStatisticsRead:  "Download product statistics",
+	PermissionRolesManage:     "Manage roles and their permissions",
+	PermissionUsersManage:     "Manage user accounts",
+	PermissionAPIKeysManage:   "Manage service accounts and their API keys",
+	PermissionSecurityRead:    "View the security event log",
+	PermissionImpersonate:     "Act as another user to reproduce their issues",
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// Security event types
+const (
+	SecurityEventRegister          = "register"
+	SecurityEventLogin             = "login"
+	SecurityEventLogout            = "logout"
+	SecurityEventLogoutAll         = "logout_all"
+	SecurityEventPasswordChange    = "password_change"
+	SecurityEventPasswordReset     = "password_reset"
+	SecurityEventMFAEnabled        = "mfa_enabled"
+	SecurityEventMFADisabled       = "mfa_disabled"
+	SecurityEventRoleChange        = "role_change"
+	SecurityEventAccountDisabled   = "account_disabled"
+	SecurityEventAccountEnabled    = "account_enabled"
+	SecurityEventAccountDeleted    = "account_deleted"
+	SecurityEventSessionRevoked    = "session_revoked"
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+	SecurityEventAPIKeyRevoked     = "api_key_revoked"
+	SecurityEventImpersonation     = "impersonation_start"
+	SecurityEventImpersonationEnd  = "impersonation_end"
+)
+
+// Security event outcomes
+const (
+	SecurityOutcomeSuccess = "success"
+	SecurityOutcomeFailure = "failure"
+)
+
+// SecurityEvent records authentication and account activity for investigations. UserID is the
+// affected account and ActorID the user who acted on it, when that was someone else.
+type SecurityEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"not null;index"`
+	Outcome   string    `json:"outcome" gorm:"not null;index"`
+	UserID    *uint     `json:"user_id" gorm:"index"`
+	ActorID   *uint     `json:"actor_id,omitempty"`
+	Email     string    `json:"email,omitempty" gorm:"index"`
+	IP        string    `json:"ip" gorm:"index"`
+	UserAgent string    `json:"user_agent"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
@@ -4,30 +4,41 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	FirstName string         `json:"first_name" gorm:"not null"`
-	LastName  string         `json:"last_name" gorm:"not null"`
-	Role      string         `json:"role" gorm:"default:'user'"`
-	Active    bool           `json:"active" gorm:"default:true"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Email           string         `json:"email" gorm:"uniqueIndex;not null"`
+	Password        string         `json:"-" gorm:"not null"`
+	FirstName       string         `json:"first_name" gorm:"not null"`
+	LastName        string         `json:"last_name" gorm:"not null"`
+	Role            string         `json:"role" gorm:"default:'user'"`
+	Active          bool           `json:"active" gorm:"default:true"`
+	EmailVerified   bool           `json:"email_verified" gorm:"default:false"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`
+	MFAEnabled      bool           `json:"mfa_enabled" gorm:"default:false"`
+	MFASecret       string         `json:"-" gorm:"serializer:encrypted"`
+	ServiceAccount  bool           `json:"service_account" gorm:"default:false;index"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// UserProfile holds contact details. Phone is encrypted at rest and matched through PhoneIndex.
 type UserProfile struct {
 	ID         uint           `json:"id" gorm:"primaryKey"`
 	UserID     uint           `json:"user_id" gorm:"uniqueIndex;not null"`
-	Phone      string         `json:"phone"`
-	Address    string         `json:"address"`
-	City       string         `json:"city"`
-	Country    string         `json:"country"`
-	PostalCode string         `json:"postal_code"`
+	Phone      string         `json:"phone" gorm:"serializer:encrypted"`
+	PhoneIndex string         `json:"-" gorm:"index"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
 }
+
+// BeforeSave keeps the phone blind index in sync with the phone number
+func (p *UserProfile) BeforeSave(tx *gorm.DB) error {
+	p.PhoneIndex = utils.BlindIndex(utils.NormalizePhone(p.Phone))
+	return nil
+}
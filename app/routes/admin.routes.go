@@ -4,23 +4,99 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/rizkyizh/go-fiber-boilerplate/app/controllers"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/middlewares"
 )
 
 func SetupAdminRoutes(app *fiber.App) {
 	adminController := controllers.NewAdminController()
+	productVariantController := controllers.NewProductVariantController()
+	productImageController := controllers.NewProductImageController()
+	attributeController := controllers.NewAttributeController()
+	categoryController := controllers.NewCategoryController()
+	brandController := controllers.NewBrandController()
+	adminAuthController := controllers.NewAdminAuthController()
+	adminAuth := middlewares.AdminAuthMiddleware()
+	userController := controllers.NewUserController()
+	apiKeyController := controllers.NewAPIKeyController()
+	securityEventController := controllers.NewSecurityEventController()
+	impersonationController := controllers.NewImpersonationController()
 
-	// Admin dashboard route
-	app.Get("/admin", adminController.Dashboard)
-
-	// Admin API routes
-	adminAPI := app.Group("/admin/api")
-	adminAPI.Get("/products", adminController.GetProducts)
-	adminAPI.Get("/products/:id", adminController.GetProductByID)
-	adminAPI.Post("/products", adminController.CreateProduct)
-	adminAPI.Put("/products/:id", adminController.UpdateProduct)
-	adminAPI.Delete("/products/:id", adminController.DeleteProduct)
-	adminAPI.Post("/products/bulk", adminController.BulkUploadProducts)
-	adminAPI.Post("/products/bulk-delete", adminController.DeleteAllProducts)
-	adminAPI.Get("/categories", adminController.GetCategories)
-	adminAPI.Post("/cache/clear", adminController.ClearCache)
+	// Admin dashboard login with a cookie session
+	app.Get("/admin/login", adminAuthController.LoginPage)
+	app.Post("/admin/login", adminAuthController.Login)
+	app.Post("/admin/login/mfa", adminAuthController.LoginMFA)
+	app.Get("/admin/login/oidc/:provider", adminAuthController.OIDCLogin)
+	app.Get("/admin/session", adminAuth, adminAuthController.Session)
+	app.Post("/admin/logout", adminAuth, adminAuthController.Logout)
+
+	// Admin dashboard route (browsers without a session are sent to the login page)
+	app.Get("/admin", adminAuth, adminController.Dashboard)
+
+	// Admin API routes (session cookie with CSRF token or Authorization header, each route guarded by a permission)
+	adminAPI := app.Group("/admin/api", adminAuth)
+	adminAPI.Get("/products", middlewares.RequirePermission(models.PermissionProductsRead), adminController.GetProducts)
+	adminAPI.Get("/products/:id", middlewares.RequirePermission(models.PermissionProductsRead), adminController.GetProductByID)
+	adminAPI.Post("/products", middlewares.RequirePermission(models.PermissionProductsWrite), adminController.CreateProduct)
+	adminAPI.Put("/products/:id", middlewares.RequirePermission(models.PermissionProductsWrite), adminController.UpdateProduct)
+	adminAPI.Delete("/products/:id", middlewares.RequirePermission(models.PermissionProductsDelete), adminController.DeleteProduct)
+	adminAPI.Post("/products/bulk", middlewares.RequirePermission(models.PermissionProductsWrite), adminController.BulkUploadProducts)
+	adminAPI.Post("/products/bulk-delete", middlewares.RequirePermission(models.PermissionProductsDelete), adminController.DeleteAllProducts)
+	adminAPI.Post("/products/:id/variants", middlewares.RequirePermission(models.PermissionProductsWrite), productVariantController.CreateVariant)
+	adminAPI.Put("/products/:id/variants/:variantId", middlewares.RequirePermission(models.PermissionProductsWrite), productVariantController.UpdateVariant)
+	adminAPI.Delete("/products/:id/variants/:variantId", middlewares.RequirePermission(models.PermissionProductsWrite), productVariantController.DeleteVariant)
+	adminAPI.Post("/products/:id/images", middlewares.RequirePermission(models.PermissionProductsWrite), productImageController.AttachImage)
+	adminAPI.Put("/products/:id/images/order", middlewares.RequirePermission(models.PermissionProductsWrite), productImageController.ReorderImages)
+	adminAPI.Put("/products/:id/images/:imageId", middlewares.RequirePermission(models.PermissionProductsWrite), productImageController.UpdateImage)
+	adminAPI.Delete("/products/:id/images/:imageId", middlewares.RequirePermission(models.PermissionProductsWrite), productImageController.DetachImage)
+	adminAPI.Get("/categories", middlewares.RequirePermission(models.PermissionCategoriesRead), adminController.GetCategories)
+	adminAPI.Post("/categories", middlewares.RequirePermission(models.PermissionCategoriesWrite), categoryController.CreateCategory)
+	adminAPI.Put("/categories/:id", middlewares.RequirePermission(models.PermissionCategoriesWrite), categoryController.UpdateCategory)
+	adminAPI.Post("/categories/:id/deactivate", middlewares.RequirePermission(models.PermissionCategoriesWrite), categoryController.DeactivateCategory)
+	adminAPI.Post("/categories/:id/reactivate", middlewares.RequirePermission(models.PermissionCategoriesWrite), categoryController.ReactivateCategory)
+	adminAPI.Post("/categories/:id/merge", middlewares.RequirePermission(models.PermissionCategoriesWrite), categoryController.MergeCategory)
+	adminAPI.Delete("/categories/:id", middlewares.RequirePermission(models.PermissionCategoriesWrite), categoryController.DeleteCategory)
+	adminAPI.Get("/categories/:id/attributes", middlewares.RequirePermission(models.PermissionCategoriesRead), attributeController.GetAttributes)
+	adminAPI.Post("/categories/:id/attributes", middlewares.RequirePermission(models.PermissionCategoriesWrite), attributeController.CreateAttribute)
+	adminAPI.Put("/categories/:id/attributes/:attributeId", middlewares.RequirePermission(models.PermissionCategoriesWrite), attributeController.UpdateAttribute)
+	adminAPI.Delete("/categories/:id/attributes/:attributeId", middlewares.RequirePermission(models.PermissionCategoriesWrite), attributeController.DeleteAttribute)
+	adminAPI.Get("/brands", middlewares.RequirePermission(models.PermissionProductsRead), brandController.GetAdminBrands)
+	adminAPI.Post("/brands", middlewares.RequirePermission(models.PermissionProductsWrite), brandController.CreateBrand)
+	adminAPI.Put("/brands/:id", middlewares.RequirePermission(models.PermissionProductsWrite), brandController.UpdateBrand)
+	adminAPI.Post("/brands/:id/aliases", middlewares.RequirePermission(models.PermissionProductsWrite), brandController.AddBrandAlias)
+	adminAPI.Delete("/brands/:id/aliases/:aliasId", middlewares.RequirePermission(models.PermissionProductsWrite), brandController.RemoveBrandAlias)
+	adminAPI.Post("/cache/clear", middlewares.RequirePermission(models.PermissionCacheClear), adminController.ClearCache)
+
+	// Role and permission management
+	adminAPI.Get("/roles", middlewares.RequirePermission(models.PermissionRolesManage), adminController.GetRoles)
+	adminAPI.Post("/roles", middlewares.RequirePermission(models.PermissionRolesManage), adminController.CreateRole)
+	adminAPI.Put("/roles/:id/permissions", middlewares.RequirePermission(models.PermissionRolesManage), adminController.UpdateRolePermissions)
+	adminAPI.Get("/permissions", middlewares.RequirePermission(models.PermissionRolesManage), adminController.GetPermissions)
+
+	// User management
+	adminAPI.Get("/users", middlewares.RequirePermission(models.PermissionUsersManage), userController.GetUsers)
+	adminAPI.Get("/users/:id", middlewares.RequirePermission(models.PermissionUsersManage), userController.GetUser)
+	adminAPI.Put("/users/:id/role", middlewares.RequirePermission(models.PermissionUsersManage), userController.UpdateUserRole)
+	adminAPI.Post("/users/:id/deactivate", middlewares.RequirePermission(models.PermissionUsersManage), userController.DeactivateUser)
+	adminAPI.Post("/users/:id/reactivate", middlewares.RequirePermission(models.PermissionUsersManage), userController.ReactivateUser)
+	adminAPI.Delete("/users/:id", middlewares.RequirePermission(models.PermissionUsersManage), userController.DeleteUser)
+	adminAPI.Post("/users/:id/restore", middlewares.RequirePermission(models.PermissionUsersManage), userController.RestoreUser)
+	adminAPI.Get("/users/:id/sessions", middlewares.RequirePermission(models.PermissionUsersManage), userController.GetUserSessions)
+	adminAPI.Delete("/users/:id/sessions/:sessionId", middlewares.RequirePermission(models.PermissionUsersManage), userController.RevokeUserSession)
+	adminAPI.Post("/users/:id/unlock", middlewares.RequirePermission(models.PermissionUsersManage), userController.UnlockUser)
+
+	// Service accounts and API keys
+	adminAPI.Post("/service-accounts", middlewares.RequirePermission(models.PermissionAPIKeysManage), apiKeyController.CreateServiceAccount)
+	adminAPI.Get("/service-accounts", middlewares.RequirePermission(models.PermissionAPIKeysManage), apiKeyController.GetServiceAccounts)
+	adminAPI.Post("/service-accounts/:id/api-keys", middlewares.RequirePermission(models.PermissionAPIKeysManage), apiKeyController.CreateAPIKey)
+	adminAPI.Get("/api-keys", middlewares.RequirePermission(models.PermissionAPIKeysManage), apiKeyController.GetAPIKeys)
+	adminAPI.Delete("/api-keys/:id", middlewares.RequirePermission(models.PermissionAPIKeysManage), apiKeyController.RevokeAPIKey)
+
+	// Impersonation with its audit trail
+	adminAPI.Post("/users/:id/impersonate", middlewares.RequirePermission(models.PermissionImpersonate), impersonationController.ImpersonateUser)
+	adminAPI.Get("/impersonations", middlewares.RequirePermission(models.PermissionImpersonate), impersonationController.GetImpersonations)
+	adminAPI.Delete("/impersonations/:id", middlewares.RequirePermission(models.PermissionImpersonate), impersonationController.EndImpersonation)
+
+	// Security event log
+	adminAPI.Get("/security-events", middlewares.RequirePermission(models.PermissionSecurityRead), securityEventController.GetSecurityEvents)
 }
@@ -9,15 +9,54 @@ import (
 
 func SetupAuthRoutes(app *fiber.App) {
 	authController := controllers.NewAuthController()
+	addressController := controllers.NewAddressController()
+	// Sensitive actions an admin impersonating the user must not take
+	noImpersonation := middlewares.ForbidImpersonation()
+
+	// Public keys for services that verify our access tokens
+	app.Get("/.well-known/jwks.json", authController.JWKS)
 
 	auth := app.Group("/api/auth")
 
 	// Public routes
 	auth.Post("/register", authController.Register)
 	auth.Post("/login", authController.Login)
+	auth.Post("/login/mfa", authController.LoginMFA)
+	auth.Post("/refresh", authController.Refresh)
+	auth.Post("/forgot-password", authController.ForgotPassword)
+	auth.Post("/reset-password", authController.ResetPassword)
+	auth.Get("/verify-email", authController.VerifyEmail)
+	auth.Post("/resend-verification", authController.ResendVerification)
+
+	// OpenID Connect single sign-on
+	auth.Get("/oidc/providers", authController.OIDCProviders)
+	auth.Get("/oidc/:provider/login", authController.OIDCLogin)
+	auth.Get("/oidc/:provider/callback", authController.OIDCCallback)
 
 	// Protected routes
 	auth.Post("/logout", middlewares.AuthMiddleware(), authController.Logout)
+	auth.Post("/logout-all", middlewares.AuthMiddleware(), noImpersonation, authController.LogoutAll)
+	auth.Get("/sessions", middlewares.AuthMiddleware(), authController.GetSessions)
+	auth.Delete("/sessions/:id", middlewares.AuthMiddleware(), noImpersonation, authController.RevokeSession)
 	auth.Get("/profile", middlewares.AuthMiddleware(), authController.GetProfile)
 	auth.Put("/profile", middlewares.AuthMiddleware(), authController.UpdateProfile)
+	auth.Put("/password", middlewares.AuthMiddleware(), noImpersonation, authController.ChangePassword)
+	auth.Get("/security-events", middlewares.AuthMiddleware(), authController.GetSecurityEvents)
+
+	// Address book
+	auth.Get("/addresses", middlewares.AuthMiddleware(), addressController.GetAddresses)
+	auth.Post("/addresses", middlewares.AuthMiddleware(), addressController.CreateAddress)
+	auth.Get("/addresses/:id", middlewares.AuthMiddleware(), addressController.GetAddress)
+	auth.Put("/addresses/:id", middlewares.AuthMiddleware(), addressController.UpdateAddress)
+	auth.Delete("/addresses/:id", middlewares.AuthMiddleware(), addressController.DeleteAddress)
+
+	// Personal data export and self-service account deletion
+	auth.Get("/me/export", middlewares.AuthMiddleware(), noImpersonation, authController.ExportData)
+	auth.Delete("/me", middlewares.AuthMiddleware(), noImpersonation, authController.DeleteAccount)
+
+	// Two-factor authentication
+	auth.Post("/mfa/enroll", middlewares.AuthMiddleware(), noImpersonation, authController.EnrollMFA)
+	auth.Post("/mfa/confirm", middlewares.AuthMiddleware(), noImpersonation, authController.ConfirmMFA)
+	auth.Post("/mfa/disable", middlewares.AuthMiddleware(), noImpersonation, authController.DisableMFA)
+	auth.Post("/mfa/recovery-codes", middlewares.AuthMiddleware(), noImpersonation, authController.RegenerateRecoveryCodes)
 }
@@ -38,6 +38,9 @@ func rateLimit(requests int, window time.Duration) fiber.Handler {
 
 func SetupProductRoutes(app *fiber.App) {
 	productController := controllers.NewProductController()
+	attributeController := controllers.NewAttributeController()
+	categoryController := controllers.NewCategoryController()
+	brandController := controllers.NewBrandController()
 
 	// Product routes with rate limiting
 	products := app.Group("/api/products")
@@ -50,5 +53,13 @@ func SetupProductRoutes(app *fiber.App) {
 	categories := app.Group("/api/categories")
 	// categories.Use(rateLimit(200, time.Minute)) // 200 requests per minute for categories
 	categories.Get("/", productController.GetCategories)
+	categories.Get("/tree", categoryController.GetCategoryTree)
 	categories.Get("/:id/products", productController.GetProductsByCategory)
+	categories.Get("/:id/attributes", attributeController.GetAttributes)
+
+	// Brand routes
+	brands := app.Group("/api/brands")
+	brands.Get("/", brandController.GetBrands)
+	brands.Get("/:id", brandController.GetBrand)
+	brands.Get("/:id/products", brandController.GetBrandProducts)
 }
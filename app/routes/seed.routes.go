@@ -4,13 +4,15 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/rizkyizh/go-fiber-boilerplate/app/controllers"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/middlewares"
 )
 
 func SetupSeedRoutes(app *fiber.App) {
 	seedController := controllers.NewSeedController()
 
 	// Seed routes
-	seed := app.Group("/api/seed")
+	seed := app.Group("/api/seed", middlewares.AuthMiddleware(), middlewares.RequirePermission(models.PermissionSeedWrite))
 	seed.Post("/products", seedController.SeedProducts)
 	seed.Delete("/clear", seedController.ClearProducts)
 }
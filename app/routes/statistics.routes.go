@@ -4,12 +4,14 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/rizkyizh/go-fiber-boilerplate/app/controllers"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/middlewares"
 )
 
 func SetupStatisticsRoutes(app *fiber.App) {
 	statisticsController := controllers.NewStatisticsController()
 
 	// Statistics routes
-	statistics := app.Group("/api/statistics")
+	statistics := app.Group("/api/statistics", middlewares.AuthMiddleware(), middlewares.RequirePermission(models.PermissionStatisticsRead))
 	statistics.Get("/download", statisticsController.DownloadStatistics)
 }
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+// maxAddressesPerUser caps the size of an address book
+const maxAddressesPerUser = 20
+
+var (
+	ErrInvalidCountry     = errors.New("country must be an ISO 3166-1 alpha-2 code")
+	ErrInvalidPostalCode  = errors.New("postal code is not valid for the country")
+	ErrAddressBookFull    = errors.New("address book is full")
+	ErrAddressFieldsEmpty = errors.New("label, line1 and city cannot be empty")
+)
+
+func init() {
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "addresses",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			var addresses []models.Address
+			err := tx.Where("user_id = ?", userID).Order("id").Find(&addresses).Error
+			return addresses, err
+		},
+		Erase: func(tx *gorm.DB, userID uint) error {
+			return tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Address{}).Error
+		},
+	})
+}
+
+type AddressService struct {
+	db *gorm.DB
+}
+
+func NewAddressService() *AddressService {
+	return &AddressService{
+		db: database.DB,
+	}
+}
+
+// GetAddresses returns the user's address book with the default addresses first
+func (s *AddressService) GetAddresses(userID uint) ([]models.Address, error) {
+	var addresses []models.Address
+	err := s.db.Where("user_id = ?", userID).
+		Order("default_shipping DESC, default_billing DESC, created_at").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+// GetAddress returns one of the user's addresses
+func (s *AddressService) GetAddress(userID, id uint) (*models.Address, error) {
+	var address models.Address
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// CreateAddress adds an address to the user's address book. The first address becomes the
+// default for both shipping and billing.
+func (s *AddressService) CreateAddress(userID uint, request dto.AddressRequest) (*models.Address, error) {
+	address := models.Address{
+		UserID:          userID,
+		Label:           strings.TrimSpace(request.Label),
+		RecipientName:   strings.TrimSpace(request.RecipientName),
+		Line1:           strings.TrimSpace(request.Line1),
+		Line2:           strings.TrimSpace(request.Line2),
+		City:            strings.TrimSpace(request.City),
+		Region:          strings.TrimSpace(request.Region),
+		PostalCode:      request.PostalCode,
+		Country:         request.Country,
+		Phone:           strings.TrimSpace(request.Phone),
+		DefaultShipping: request.DefaultShipping,
+		DefaultBilling:  request.DefaultBilling,
+	}
+	if err := normalizeAddress(&address); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Address{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxAddressesPerUser {
+			return ErrAddressBookFull
+		}
+		if count == 0 {
+			address.DefaultShipping = true
+			address.DefaultBilling = true
+		}
+
+		if err := clearDefaultAddresses(tx, userID, 0, address.DefaultShipping, address.DefaultBilling); err != nil {
+			return err
+		}
+		return tx.Create(&address).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+// UpdateAddress changes the given fields of one of the user's addresses. Making it a default
+// takes the flag away from the previous default.
+func (s *AddressService) UpdateAddress(userID, id uint, request dto.UpdateAddressRequest) (*models.Address, error) {
+	var address models.Address
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&address).Error; err != nil {
+			return err
+		}
+
+		if request.Label != nil {
+			address.Label = strings.TrimSpace(*request.Label)
+		}
+		if request.RecipientName != nil {
+			address.RecipientName = strings.TrimSpace(*request.RecipientName)
+		}
+		if request.Line1 != nil {
+			address.Line1 = strings.TrimSpace(*request.Line1)
+		}
+		if request.Line2 != nil {
+			address.Line2 = strings.TrimSpace(*request.Line2)
+		}
+		if request.City != nil {
+			address.City = strings.TrimSpace(*request.City)
+		}
+		if request.Region != nil {
+			address.Region = strings.TrimSpace(*request.Region)
+		}
+		if request.PostalCode != nil {
+			address.PostalCode = *request.PostalCode
+		}
+		if request.Country != nil {
+			address.Country = *request.Country
+		}
+		if request.Phone != nil {
+			address.Phone = strings.TrimSpace(*request.Phone)
+		}
+		if request.DefaultShipping != nil {
+			address.DefaultShipping = *request.DefaultShipping
+		}
+		if request.DefaultBilling != nil {
+			address.DefaultBilling = *request.DefaultBilling
+		}
+
+		if err := normalizeAddress(&address); err != nil {
+			return err
+		}
+		if err := clearDefaultAddresses(tx, userID, address.ID, address.DefaultShipping, address.DefaultBilling); err != nil {
+			return err
+		}
+		return tx.Save(&address).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+// DeleteAddress removes an address. When it was a default, the most recently added remaining
+// address takes over that role.
+func (s *AddressService) DeleteAddress(userID, id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var address models.Address
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&address).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+
+		if !address.DefaultShipping && !address.DefaultBilling {
+			return nil
+		}
+
+		var replacement models.Address
+		err := tx.Where("user_id = ?", userID).Order("created_at DESC").First(&replacement).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if address.DefaultShipping {
+			updates["default_shipping"] = true
+		}
+		if address.DefaultBilling {
+			updates["default_billing"] = true
+		}
+		return tx.Model(&replacement).Updates(updates).Error
+	})
+}
+
+// clearDefaultAddresses removes the default flags from the user's other addresses
+func clearDefaultAddresses(tx *gorm.DB, userID, exceptID uint, shipping, billing bool) error {
+	if shipping {
+		if err := tx.Model(&models.Address{}).
+			Where("user_id = ? AND id <> ? AND default_shipping", userID, exceptID).
+			Update("default_shipping", false).Error; err != nil {
+			return err
+		}
+	}
+	if billing {
+		if err := tx.Model(&models.Address{}).
+			Where("user_id = ? AND id <> ? AND default_billing", userID, exceptID).
+			Update("default_billing", false).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeAddress checks the required fields and normalises and validates the country code
+// and postal code
+func normalizeAddress(address *models.Address) error {
+	if address.Label == "" || address.Line1 == "" || address.City == "" {
+		return ErrAddressFieldsEmpty
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(address.Country))
+	if !utils.IsCountryCode(country) {
+		return ErrInvalidCountry
+	}
+	address.Country = country
+
+	address.PostalCode = utils.NormalizePostalCode(address.PostalCode)
+	if !utils.ValidatePostalCode(country, address.PostalCode) {
+		return ErrInvalidPostalCode
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+const (
+	// AdminSessionCookie holds the HttpOnly admin session token
+	AdminSessionCookie = "admin_session"
+	// AdminCSRFCookie holds the CSRF token for the dashboard script to echo in CSRFHeader
+	AdminCSRFCookie = "admin_csrf"
+	CSRFHeader      = "X-CSRF-Token"
+)
+
+var (
+	ErrInvalidAdminSession = errors.New("admin session expired or revoked")
+	ErrNoAdminAccess       = errors.New("your role has no access to the admin dashboard")
+)
+
+// AdminSession is a cookie-based login to the admin dashboard. It belongs to a token family
+// like any other session, so it is listed with the user's sessions and revoked with them.
+type AdminSession struct {
+	Token     string
+	CSRFToken string
+	SessionID string
+	MFA       bool
+	User      models.User
+}
+
+type AdminSessionService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	roleService *RoleService
+}
+
+func NewAdminSessionService() *AdminSessionService {
+	return &AdminSessionService{
+		db:          database.DB,
+		redis:       database.Redis,
+		roleService: NewRoleService(),
+	}
+}
+
+// Create starts an admin session for a user whose role has at least one permission
+func (s *AdminSessionService) Create(user models.User, mfa bool, device Device) (*AdminSession, error) {
+	permissions, err := s.roleService.GetPermissionsForRole(user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) == 0 {
+		return nil, ErrNoAdminAccess
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	family, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	ttl := config.AppConfig.AdminSessionTTL
+	sessionKey := adminSessionKey(token)
+	familyKey := "refresh_family:" + family
+	userKey := userFamiliesKey(user.ID)
+
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, sessionKey, "user_id", user.ID, "family", family, "mfa", mfa, "csrf", csrfToken)
+	pipe.Expire(ctx, sessionKey, ttl)
+	pipe.SAdd(ctx, familyKey, sessionKey)
+	pipe.Expire(ctx, familyKey, ttl)
+	pipe.SAdd(ctx, userKey, family)
+	pipe.Expire(ctx, userKey, config.AppConfig.RefreshTokenTTL)
+	recordSession(ctx, pipe, family, user.ID, device, mfa, true)
+	// Admin sessions cannot be refreshed, so their metadata goes with them
+	pipe.Expire(ctx, sessionMetaKey(family), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return &AdminSession{
+		Token:     token,
+		CSRFToken: csrfToken,
+		SessionID: family,
+		MFA:       mfa,
+		User:      user,
+	}, nil
+}
+
+// Authenticate resolves a session cookie to its live session and active user
+func (s *AdminSessionService) Authenticate(token string) (*AdminSession, error) {
+	if token == "" {
+		return nil, ErrInvalidAdminSession
+	}
+
+	data, err := s.redis.HGetAll(context.Background(), adminSessionKey(token)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrInvalidAdminSession
+	}
+
+	userID, err := strconv.ParseUint(data["user_id"], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidAdminSession
+	}
+
+	var user models.User
+	if err := s.db.Where("id = ? AND active = ? AND service_account = ?", userID, true, false).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAdminSession
+		}
+		return nil, err
+	}
+
+	return &AdminSession{
+		Token:     token,
+		CSRFToken: data["csrf"],
+		SessionID: data["family"],
+		MFA:       data["mfa"] == "1",
+		User:      user,
+	}, nil
+}
+
+// Destroy ends the session behind a cookie
+func (s *AdminSessionService) Destroy(token string) error {
+	ctx := context.Background()
+	key := adminSessionKey(token)
+
+	data, err := s.redis.HMGet(ctx, key, "user_id", "family").Result()
+	if err != nil {
+		return err
+	}
+	userID, _ := data[0].(string)
+	family, _ := data[1].(string)
+	if family == "" {
+		return ErrInvalidAdminSession
+	}
+
+	revokeTokenFamily(ctx, s.redis, family)
+	return s.redis.SRem(ctx, "user_sessions:"+userID, family).Err()
+}
+
+func adminSessionKey(token string) string {
+	return "admin_session:" + utils.HashToken(token)
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+// APIKeyPrefix marks keys issued by this API so they are easy to spot in logs and secret scanners
+const APIKeyPrefix = "hpk"
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid API key")
+	ErrNotServiceAccount    = errors.New("API keys can only be issued to service accounts")
+	ErrUnknownRole          = errors.New("unknown role")
+	ErrScopeNotPermitted    = errors.New("scope is not granted to the service account role")
+	ErrAPIKeyAlreadyRevoked = errors.New("API key is already revoked")
+)
+
+type APIKeyService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	roleService *RoleService
+}
+
+func NewAPIKeyService() *APIKeyService {
+	return &APIKeyService{
+		db:          database.DB,
+		redis:       database.Redis,
+		roleService: NewRoleService(),
+	}
+}
+
+// CreateServiceAccount creates a user that cannot log in with a password and only authenticates with API keys
+func (s *APIKeyService) CreateServiceAccount(name, role string) (*models.User, error) {
+	exists, err := s.roleService.RoleExists(role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUnknownRole
+	}
+
+	// Service accounts never use their password, so store an unguessable one
+	password, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix, err := randomHex(4)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		Email:          "svc-" + suffix + "@service-accounts.local",
+		Password:       hashedPassword,
+		FirstName:      name,
+		LastName:       "Service Account",
+		Role:           role,
+		Active:         true,
+		EmailVerified:  true,
+		ServiceAccount: true,
+	}
+	if err := s.db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (s *APIKeyService) GetServiceAccounts() ([]models.User, error) {
+	var users []models.User
+	if err := s.db.Where("service_account = ?", true).Order("created_at DESC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CreateKey issues a new key for a service account. The plain key is only returned here.
+func (s *APIKeyService) CreateKey(serviceAccountID uint, name string, scopes []string, expiresAt *time.Time, createdBy uint) (*models.APIKey, string, error) {
+	var user models.User
+	if err := s.db.First(&user, serviceAccountID).Error; err != nil {
+		return nil, "", err
+	}
+	if !user.ServiceAccount {
+		return nil, "", ErrNotServiceAccount
+	}
+
+	// A key can never do more than the role of its service account
+	granted, err := s.roleService.GetPermissionsForRole(user.Role)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return nil, "", errors.New(ErrScopeNotPermitted.Error() + ": " + scope)
+		}
+	}
+
+	// The public part is hex so it never contains the "_" separator
+	publicID, err := randomHex(4)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefix := APIKeyPrefix + "_" + publicID
+	rawKey := prefix + "_" + secret
+
+	key := models.APIKey{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedBy: createdBy,
+	}
+	if err := s.db.Create(&key).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &key, rawKey, nil
+}
+
+// GetKeys lists API keys, optionally only those of one service account
+func (s *APIKeyService) GetKeys(serviceAccountID *uint) ([]models.APIKey, error) {
+	query := s.db.Model(&models.APIKey{}).Order("created_at DESC")
+	if serviceAccountID != nil {
+		query = query.Where("user_id = ?", *serviceAccountID)
+	}
+
+	var keys []models.APIKey
+	if err := query.Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) RevokeKey(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.db.First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyAlreadyRevoked
+	}
+
+	if err := s.db.Model(&key).Update("revoked_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Authenticate resolves a raw key to the key record and its active service account
+func (s *APIKeyService) Authenticate(rawKey string) (*models.APIKey, *models.User, error) {
+	parts := strings.SplitN(rawKey, "_", 3)
+	if len(parts) != 3 || parts[0] != APIKeyPrefix {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	var key models.APIKey
+	if err := s.db.Preload("User").Where("prefix = ?", parts[0]+"_"+parts[1]).First(&key).Error; err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(utils.HashToken(rawKey))) != 1 {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now())) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if !key.User.Active || !key.User.ServiceAccount {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	s.touch(key.ID)
+
+	return &key, &key.User, nil
+}
+
+// touch records the last use of a key, writing to the database at most once a minute per key
+func (s *APIKeyService) touch(id uint) {
+	ctx := context.Background()
+	first, err := s.redis.SetNX(ctx, "api_key_used:"+strconv.FormatUint(uint64(id), 10), 1, time.Minute).Result()
+	if err != nil || !first {
+		return
+	}
+	s.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", time.Now())
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
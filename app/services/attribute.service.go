@@ -0,0 +1,652 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+)
+
+var (
+	ErrInvalidAttribute      = errors.New("invalid attribute")
+	ErrInvalidAttributeKey   = errors.New("attribute key may only contain lowercase letters, digits and underscores")
+	ErrAttributeExists       = errors.New("the category already has an attribute with this key")
+	ErrAttributeOptionsEmpty = errors.New("enum attributes need at least one option")
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// maxAttributeTextLength limits free-text attribute values
+const maxAttributeTextLength = 500
+
+// AttributeFilter narrows a product search down to products with a matching attribute value.
+// A product matches when its value equals any of Values (case-insensitive) and lies within
+// Min and Max.
+type AttributeFilter struct {
+	Key    string
+	Values []string
+	Min    *float64
+	Max    *float64
+}
+
+type AttributeService struct {
+	db             *gorm.DB
+	productService *ProductService
+}
+
+func NewAttributeService() *AttributeService {
+	return &AttributeService{
+		db:             database.DB,
+		productService: NewProductService(),
+	}
+}
+
+// GetDefinitions returns the attributes of a category in display order
+func (s *AttributeService) GetDefinitions(categoryID uint) ([]models.AttributeDefinition, error) {
+	if err := s.db.Select("id").First(&models.Category{}, categoryID).Error; err != nil {
+		return nil, err
+	}
+	return categoryAttributes(s.db, categoryID)
+}
+
+// CreateDefinition adds an attribute to a category
+func (s *AttributeService) CreateDefinition(categoryID uint, request dto.CreateAttributeDefinitionRequest) (*models.AttributeDefinition, error) {
+	key := strings.TrimSpace(request.Key)
+	if key == "" {
+		key = attributeKey(request.Name)
+	}
+	if !attributeKeyPattern.MatchString(key) {
+		return nil, ErrInvalidAttributeKey
+	}
+
+	options := cleanAttributeOptions(request.Options)
+	if request.Type == models.AttributeTypeEnum && len(options) == 0 {
+		return nil, ErrAttributeOptionsEmpty
+	}
+	if request.Type != models.AttributeTypeEnum {
+		options = nil
+	}
+
+	definition := models.AttributeDefinition{
+		CategoryID: categoryID,
+		Key:        key,
+		Name:       strings.TrimSpace(request.Name),
+		Type:       request.Type,
+		Unit:       strings.TrimSpace(request.Unit),
+		Options:    options,
+		Required:   request.Required,
+		Position:   request.Position,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.Category{}, categoryID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.AttributeDefinition{}).Where("category_id = ? AND key = ?", categoryID, key).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAttributeExists
+		}
+
+		return tx.Omit("Category").Create(&definition).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &definition, nil
+}
+
+// UpdateDefinition changes the name, unit, options, required flag or position of an attribute.
+// Stored values that are no longer valid enum options are kept until the product is edited.
+func (s *AttributeService) UpdateDefinition(categoryID, id uint, request dto.UpdateAttributeDefinitionRequest) (*models.AttributeDefinition, error) {
+	var definition models.AttributeDefinition
+	if err := s.db.Where("id = ? AND category_id = ?", id, categoryID).First(&definition).Error; err != nil {
+		return nil, err
+	}
+
+	if request.Name != nil {
+		definition.Name = strings.TrimSpace(*request.Name)
+	}
+	if request.Unit != nil {
+		definition.Unit = strings.TrimSpace(*request.Unit)
+	}
+	if request.Options != nil && definition.Type == models.AttributeTypeEnum {
+		options := cleanAttributeOptions(request.Options)
+		if len(options) == 0 {
+			return nil, ErrAttributeOptionsEmpty
+		}
+		definition.Options = options
+	}
+	if request.Required != nil {
+		definition.Required = *request.Required
+	}
+	if request.Position != nil {
+		definition.Position = *request.Position
+	}
+
+	if err := s.db.Omit("Category").Save(&definition).Error; err != nil {
+		return nil, err
+	}
+
+	// Cached products include the attribute's name and unit
+	s.productService.clearCatalogCaches()
+
+	return &definition, nil
+}
+
+// DeleteDefinition removes an attribute and its value on every product
+func (s *AttributeService) DeleteDefinition(categoryID, id uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attribute_id = ?", id).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND category_id = ?", id, categoryID).Delete(&models.AttributeDefinition{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.productService.clearCatalogCaches()
+
+	return nil
+}
+
+// categoryAttributes loads the attribute definitions of a category in display order
+func categoryAttributes(db *gorm.DB, categoryID uint) ([]models.AttributeDefinition, error) {
+	var definitions []models.AttributeDefinition
+	err := db.Where("category_id = ?", categoryID).Order("position, id").Find(&definitions).Error
+	return definitions, err
+}
+
+// attributeDefinitionsByCategory loads the attribute definitions of several categories at once
+func attributeDefinitionsByCategory(db *gorm.DB, categoryIDs []uint) (map[uint][]models.AttributeDefinition, error) {
+	byCategory := make(map[uint][]models.AttributeDefinition)
+	if len(categoryIDs) == 0 {
+		return byCategory, nil
+	}
+
+	var definitions []models.AttributeDefinition
+	if err := db.Where("category_id IN ?", categoryIDs).Order("position, id").Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	for _, definition := range definitions {
+		byCategory[definition.CategoryID] = append(byCategory[definition.CategoryID], definition)
+	}
+	return byCategory, nil
+}
+
+// createProductAttributes stores the attributes of a new product. Every required attribute of the
+// product's category must be given.
+func createProductAttributes(tx *gorm.DB, product models.Product, input map[string]interface{}) ([]models.ProductAttributeValue, error) {
+	definitions, err := categoryAttributes(tx, product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := resolveAttributeValues(definitions, input, true)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+
+	for i := range values {
+		values[i].ProductID = product.ID
+	}
+	if err := tx.Omit("Attribute").Create(&values).Error; err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// updateProductAttributes sets the given attributes of a product and removes those whose value
+// is null. When the product moved to another category, values of attributes the new category
+// does not have are dropped. Required attributes must still have a value afterwards.
+func updateProductAttributes(tx *gorm.DB, product models.Product, input map[string]interface{}, categoryChanged bool) error {
+	definitions, err := categoryAttributes(tx, product.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	if categoryChanged {
+		ids := make([]uint, len(definitions))
+		for i, definition := range definitions {
+			ids[i] = definition.ID
+		}
+		query := tx.Where("product_id = ?", product.ID)
+		if len(ids) > 0 {
+			query = query.Where("attribute_id NOT IN ?", ids)
+		}
+		if err := query.Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedAttributeNames(input) {
+		definition, ok := findAttribute(definitions, name)
+		if !ok {
+			return fmt.Errorf("%w: %s is not an attribute of the product's category", ErrInvalidAttribute, name)
+		}
+
+		if input[name] == nil {
+			err := tx.Where("product_id = ? AND attribute_id = ?", product.ID, definition.ID).
+				Delete(&models.ProductAttributeValue{}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := resolveAttributeValue(definition, input[name])
+		if err != nil {
+			return err
+		}
+		value.ProductID = product.ID
+		err = tx.Omit("Attribute").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "attribute_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value_text", "value_number", "value_bool", "updated_at"}),
+		}).Create(&value).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, definition := range definitions {
+		if !definition.Required {
+			continue
+		}
+		var count int64
+		err := tx.Model(&models.ProductAttributeValue{}).
+			Where("product_id = ? AND attribute_id = ?", product.ID, definition.ID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: %s is required", ErrInvalidAttribute, definition.Key)
+		}
+	}
+
+	return nil
+}
+
+// resolveAttributeValues checks raw attribute values against the definitions of a category.
+// Null values are skipped. With requireAll, every required attribute must have a value.
+func resolveAttributeValues(definitions []models.AttributeDefinition, input map[string]interface{}, requireAll bool) ([]models.ProductAttributeValue, error) {
+	seen := make(map[uint]bool, len(input))
+	values := make([]models.ProductAttributeValue, 0, len(input))
+
+	for _, name := range sortedAttributeNames(input) {
+		definition, ok := findAttribute(definitions, name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an attribute of the product's category", ErrInvalidAttribute, name)
+		}
+		if input[name] == nil {
+			continue
+		}
+		if seen[definition.ID] {
+			return nil, fmt.Errorf("%w: %s is given twice", ErrInvalidAttribute, definition.Key)
+		}
+		seen[definition.ID] = true
+
+		value, err := resolveAttributeValue(definition, input[name])
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	if requireAll {
+		for _, definition := range definitions {
+			if definition.Required && !seen[definition.ID] {
+				return nil, fmt.Errorf("%w: %s is required", ErrInvalidAttribute, definition.Key)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// resolveAttributeValue converts a raw JSON value to the attribute's type. Numbers and booleans
+// may also be given as strings, as spreadsheets exported to JSON often do.
+func resolveAttributeValue(definition models.AttributeDefinition, raw interface{}) (models.ProductAttributeValue, error) {
+	value := models.ProductAttributeValue{
+		AttributeID: definition.ID,
+		Attribute:   definition,
+	}
+
+	switch definition.Type {
+	case models.AttributeTypeInteger:
+		number, ok := attributeNumber(raw)
+		if !ok || number != math.Trunc(number) {
+			return value, fmt.Errorf("%w: %s must be a whole number", ErrInvalidAttribute, definition.Key)
+		}
+		value.ValueNumber = &number
+	case models.AttributeTypeDecimal:
+		number, ok := attributeNumber(raw)
+		if !ok {
+			return value, fmt.Errorf("%w: %s must be a number", ErrInvalidAttribute, definition.Key)
+		}
+		value.ValueNumber = &number
+	case models.AttributeTypeBoolean:
+		flag, ok := attributeBool(raw)
+		if !ok {
+			return value, fmt.Errorf("%w: %s must be true or false", ErrInvalidAttribute, definition.Key)
+		}
+		value.ValueBool = &flag
+	case models.AttributeTypeEnum:
+		text, _ := raw.(string)
+		option, ok := findAttributeOption(definition.Options, text)
+		if !ok {
+			return value, fmt.Errorf("%w: %s must be one of %s", ErrInvalidAttribute, definition.Key, strings.Join(definition.Options, ", "))
+		}
+		value.ValueText = option
+	default:
+		text, ok := raw.(string)
+		text = strings.TrimSpace(text)
+		if !ok || text == "" || len(text) > maxAttributeTextLength {
+			return value, fmt.Errorf("%w: %s must be text of at most %d characters", ErrInvalidAttribute, definition.Key, maxAttributeTextLength)
+		}
+		value.ValueText = text
+	}
+
+	return value, nil
+}
+
+func attributeNumber(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	case int:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil && !math.IsNaN(number) && !math.IsInf(number, 0)
+	}
+	return 0, false
+}
+
+func attributeBool(raw interface{}) (bool, bool) {
+	switch v := raw.(type) {
+	case bool:
+		return v, true
+	case string:
+		flag, err := strconv.ParseBool(strings.TrimSpace(v))
+		return flag, err == nil
+	}
+	return false, false
+}
+
+// findAttribute looks an attribute up by key, or by its name ignoring case, as bulk files tend to use names
+func findAttribute(definitions []models.AttributeDefinition, name string) (models.AttributeDefinition, bool) {
+	for _, definition := range definitions {
+		if definition.Key == name {
+			return definition, true
+		}
+	}
+	for _, definition := range definitions {
+		if strings.EqualFold(definition.Name, strings.TrimSpace(name)) {
+			return definition, true
+		}
+	}
+	return models.AttributeDefinition{}, false
+}
+
+// findAttributeOption returns the option matching text, ignoring case
+func findAttributeOption(options []string, text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	for _, option := range options {
+		if strings.EqualFold(option, text) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+func sortedAttributeNames(input map[string]interface{}) []string {
+	names := make([]string, 0, len(input))
+	for name := range input {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// attributeKey derives a key such as "screen_size" from an attribute name
+func attributeKey(name string) string {
+	var key strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			key.WriteRune(r)
+			underscore = false
+		} else if !underscore && key.Len() > 0 {
+			key.WriteRune('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(key.String(), "_")
+}
+
+// cleanAttributeOptions trims enum options and drops empty and duplicate ones
+func cleanAttributeOptions(options []string) []string {
+	var cleaned []string
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		if _, exists := findAttributeOption(cleaned, option); exists {
+			continue
+		}
+		cleaned = append(cleaned, option)
+	}
+	return cleaned
+}
+
+// applyAttributeFilters adds one condition per filter to a product query
+func applyAttributeFilters(query *gorm.DB, filters []AttributeFilter) *gorm.DB {
+	for _, filter := range filters {
+		conditions := []string{"ad.key = ?"}
+		args := []interface{}{filter.Key}
+
+		if len(filter.Values) > 0 {
+			var texts []string
+			var numbers []float64
+			var flags []bool
+			for _, value := range filter.Values {
+				texts = append(texts, strings.ToLower(value))
+				if number, err := strconv.ParseFloat(value, 64); err == nil {
+					numbers = append(numbers, number)
+				}
+				if flag, err := strconv.ParseBool(value); err == nil {
+					flags = append(flags, flag)
+				}
+			}
+
+			matches := []string{"LOWER(pav.value_text) IN ?"}
+			matchArgs := []interface{}{texts}
+			if len(numbers) > 0 {
+				matches = append(matches, "pav.value_number IN ?")
+				matchArgs = append(matchArgs, numbers)
+			}
+			if len(flags) > 0 {
+				matches = append(matches, "pav.value_bool IN ?")
+				matchArgs = append(matchArgs, flags)
+			}
+			conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+			args = append(args, matchArgs...)
+		}
+		if filter.Min != nil {
+			conditions = append(conditions, "pav.value_number >= ?")
+			args = append(args, *filter.Min)
+		}
+		if filter.Max != nil {
+			conditions = append(conditions, "pav.value_number <= ?")
+			args = append(args, *filter.Max)
+		}
+
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM product_attribute_values pav JOIN attribute_definitions ad ON ad.id = pav.attribute_id "+
+				"WHERE pav.product_id = products.id AND "+strings.Join(conditions, " AND ")+")",
+			args...)
+	}
+	return query
+}
+
+// attributeFiltersCacheKey describes the filters for the search cache key
+func attributeFiltersCacheKey(filters []AttributeFilter) string {
+	parts := make([]string, len(filters))
+	for i, filter := range filters {
+		part := filter.Key + "=" + strings.Join(filter.Values, ",")
+		if filter.Min != nil {
+			part += fmt.Sprintf(">%g", *filter.Min)
+		}
+		if filter.Max != nil {
+			part += fmt.Sprintf("<%g", *filter.Max)
+		}
+		parts[i] = part
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "&")
+}
+
+// productIDsBySlug looks up newly uploaded products by their generated slugs, as COPY does not
+// return IDs. A slug that is not found is an error, so no attributes are silently dropped.
+func productIDsBySlug(ctx context.Context, tx pgx.Tx, slugs []string) (map[string]uint, error) {
+	rows, err := tx.Query(ctx, "SELECT id, slug FROM products WHERE slug = ANY($1) ORDER BY id DESC", slugs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up products: %v", err)
+	}
+	defer rows.Close()
+
+	productIDs := make(map[string]uint, len(slugs))
+	for rows.Next() {
+		var id uint
+		var slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			return nil, fmt.Errorf("failed to look up products: %v", err)
+		}
+		if _, exists := productIDs[slug]; !exists {
+			productIDs[slug] = id
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to look up products: %v", err)
+	}
+
+	for _, slug := range slugs {
+		if _, exists := productIDs[slug]; !exists {
+			return nil, fmt.Errorf("product '%s' was not found after insert", slug)
+		}
+	}
+	return productIDs, nil
+}
+
+// productAttributeValuesTx returns the stored attribute values of a product inside a transaction
+func productAttributeValuesTx(ctx context.Context, tx pgx.Tx, productID uint) ([]models.ProductAttributeValue, error) {
+	rows, err := tx.Query(ctx,
+		"SELECT attribute_id, value_text, value_number, value_bool FROM product_attribute_values WHERE product_id = $1",
+		productID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product attributes: %v", err)
+	}
+	defer rows.Close()
+
+	var values []models.ProductAttributeValue
+	for rows.Next() {
+		value := models.ProductAttributeValue{ProductID: productID}
+		if err := rows.Scan(&value.AttributeID, &value.ValueText, &value.ValueNumber, &value.ValueBool); err != nil {
+			return nil, fmt.Errorf("failed to load product attributes: %v", err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load product attributes: %v", err)
+	}
+	return values, nil
+}
+
+// sameAttributeValues reports whether two sets of attribute values hold the same value for
+// every attribute
+func sameAttributeValues(a, b []models.ProductAttributeValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	byAttribute := make(map[uint]models.ProductAttributeValue, len(a))
+	for _, value := range a {
+		byAttribute[value.AttributeID] = value
+	}
+	for _, value := range b {
+		other, exists := byAttribute[value.AttributeID]
+		if !exists || other.ValueText != value.ValueText ||
+			!equalPointers(other.ValueNumber, value.ValueNumber) || !equalPointers(other.ValueBool, value.ValueBool) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalPointers[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// insertAttributeValues copies the attributes of uploaded products, keyed by product ID
+func insertAttributeValues(ctx context.Context, tx pgx.Tx, valuesByProduct map[uint][]models.ProductAttributeValue) error {
+	var copyRows [][]interface{}
+	timestamp := time.Now()
+	for productID, values := range valuesByProduct {
+		for _, value := range values {
+			copyRows = append(copyRows, []interface{}{
+				productID,
+				value.AttributeID,
+				value.ValueText,
+				value.ValueNumber,
+				value.ValueBool,
+				timestamp,
+				timestamp,
+			})
+		}
+	}
+	if len(copyRows) == 0 {
+		return nil
+	}
+
+	_, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"product_attribute_values"},
+		[]string{"product_id", "attribute_id", "value_text", "value_number", "value_bool", "created_at", "updated_at"},
+		pgx.CopyFromRows(copyRows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy product attributes: %v", err)
+	}
+
+	return nil
+}
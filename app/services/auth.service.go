@@ -3,27 +3,77 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
 	"github.com/rizkyizh/go-fiber-boilerplate/config"
 	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrInvalidResetToken   = errors.New("invalid or expired reset token")
+	ErrEmailNotVerified    = errors.New("email address has not been verified")
+	ErrInvalidVerifyToken  = errors.New("invalid or expired verification link")
+	ErrInvalidPassword     = errors.New("current password is incorrect")
+)
+
+// ThrottledError is returned when an action was requested again too soon
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return "too many requests, retry after " + e.RetryAfter.Round(time.Second).String()
+}
+
+// RefreshTokenReusedError reports a replayed refresh token together with the account it
+// belongs to. It matches ErrRefreshTokenReused with errors.Is.
+type RefreshTokenReusedError struct {
+	UserID uint
+}
+
+func (e *RefreshTokenReusedError) Error() string {
+	return ErrRefreshTokenReused.Error()
+}
+
+func (e *RefreshTokenReusedError) Is(target error) bool {
+	return target == ErrRefreshTokenReused
+}
+
+// TokenPair holds a short-lived access token and the refresh token used to renew it
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
+}
+
 type AuthService struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db         *gorm.DB
+	redis      *redis.Client
+	mailer     Mailer
+	loginGuard *LoginGuard
+	mfa        *MFAService
+	policy     *PasswordPolicy
 }
 
 func NewAuthService() *AuthService {
 	return &AuthService{
-		db:    database.DB,
-		redis: database.Redis,
+		db:         database.DB,
+		redis:      database.Redis,
+		mailer:     NewMailer(),
+		loginGuard: NewLoginGuard(),
+		mfa:        NewMFAService(),
+		policy:     NewPasswordPolicy(),
 	}
 }
 
@@ -34,15 +84,19 @@ func (s *AuthService) Register(email, password, firstName, lastName string) (*mo
 		return nil, errors.New("user already exists")
 	}
 
+	if err := s.policy.Validate(password); err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
 		return nil, err
 	}
 
 	user := models.User{
 		Email:     email,
-		Password:  string(hashedPassword),
+		Password:  hashedPassword,
 		FirstName: firstName,
 		LastName:  lastName,
 		Role:      "user",
@@ -53,29 +107,147 @@ func (s *AuthService) Register(email, password, firstName, lastName string) (*mo
 		return nil, err
 	}
 
+	// The account is usable even if the email could not be sent; the user can ask for a resend
+	if err := s.SendVerificationEmail(user); err != nil {
+		log.Printf("Error sending verification email to %s: %v", user.Email, err)
+	}
+
 	return &user, nil
 }
 
-func (s *AuthService) Login(email, password string) (*models.User, string, error) {
+func (s *AuthService) Login(email, password string, device Device) (*models.User, *TokenPair, error) {
+	user, err := s.Authenticate(email, password, device.IP)
+	if err != nil {
+		return user, nil, err
+	}
+
+	tokens, err := s.IssueTokens(*user, false, device)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// Authenticate checks an email and password without starting a session. Accounts with 2FA get
+// an MFAChallenge error holding a short-lived mfa_pending token instead.
+func (s *AuthService) Authenticate(email, password, ip string) (*models.User, error) {
+	if err := s.loginGuard.Check(email, ip); err != nil {
+		return nil, err
+	}
+
+	// Service accounts can only authenticate with API keys
 	var user models.User
-	if err := s.db.Where("email = ? AND active = ?", email, true).First(&user).Error; err != nil {
-		return nil, "", errors.New("invalid credentials")
+	if err := s.db.Where("email = ? AND active = ? AND service_account = ?", email, true, false).First(&user).Error; err != nil {
+		s.loginGuard.RecordFailure(email, ip)
+		return nil, errors.New("invalid credentials")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, "", errors.New("invalid credentials")
+	ok, needsRehash := utils.VerifyPassword(user.Password, password)
+	if !ok {
+		s.loginGuard.RecordFailure(email, ip)
+		return nil, errors.New("invalid credentials")
 	}
 
-	token, err := s.GenerateJWT(user)
+	// With 2FA the failure history is only cleared once the second factor has been passed
+	if !user.MFAEnabled {
+		s.loginGuard.RecordSuccess(email)
+	}
+
+	// Upgrade bcrypt and outdated argon2id hashes while the plain password is at hand
+	if needsRehash {
+		s.rehashPassword(user.ID, password)
+	}
+
+	if config.AppConfig.RequireEmailVerification && !user.EmailVerified {
+		return &user, ErrEmailNotVerified
+	}
+
+	if user.MFAEnabled {
+		challenge, err := s.mfa.CreateLoginChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &user, challenge
+	}
+
+	return &user, nil
+}
+
+// CompleteMFALogin exchanges an mfa_pending token and a TOTP or recovery code for real tokens
+func (s *AuthService) CompleteMFALogin(mfaToken, code string, device Device) (*models.User, *TokenPair, error) {
+	user, err := s.VerifyMFAChallenge(mfaToken, code, device.IP)
 	if err != nil {
-		return nil, "", err
+		return user, nil, err
 	}
 
-	// Store token in Redis for session management
+	tokens, err := s.IssueTokens(*user, true, device)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// VerifyMFAChallenge checks the second factor for an mfa_pending token without starting a session.
+// Wrong codes are throttled and locked out like wrong passwords.
+func (s *AuthService) VerifyMFAChallenge(mfaToken, code, ip string) (*models.User, error) {
+	return s.mfa.CompleteLoginChallenge(mfaToken, code, ip)
+}
+
+// IssueTokens starts a new session (refresh token family) on the device and returns the first
+// token pair. mfa records whether the login was completed with a second factor.
+func (s *AuthService) IssueTokens(user models.User, mfa bool, device Device) (*TokenPair, error) {
+	family, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokenPair(user, family, mfa, device, true)
+}
+
+// Refresh rotates a refresh token. Presenting a token that was already rotated
+// revokes the whole family, including the access tokens issued from it.
+func (s *AuthService) Refresh(refreshToken string, device Device) (*models.User, *TokenPair, error) {
 	ctx := context.Background()
-	s.redis.Set(ctx, "session:"+token, user.ID, 24*time.Hour)
+	key := "refresh:" + utils.HashToken(refreshToken)
 
-	return &user, token, nil
+	data, err := s.redis.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	family := data["family"]
+
+	// Mark the token as used atomically so concurrent replays are caught as well
+	firstUse, err := s.redis.HSetNX(ctx, key, "used_at", time.Now().Unix()).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	userID, err := strconv.ParseUint(data["user_id"], 10, 64)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if !firstUse {
+		revokeTokenFamily(ctx, s.redis, family)
+		return nil, nil, &RefreshTokenReusedError{UserID: uint(userID)}
+	}
+
+	var user models.User
+	if err := s.db.Where("id = ? AND active = ?", userID, true).First(&user).Error; err != nil {
+		revokeTokenFamily(ctx, s.redis, family)
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	tokens, err := s.issueTokenPair(user, family, data["mfa"] == "1", device, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &user, tokens, nil
 }
 
 func (s *AuthService) Logout(token string) error {
@@ -84,6 +256,344 @@ func (s *AuthService) Logout(token string) error {
 	return nil
 }
 
+// RevokeAllSessions ends every session and refresh token family belonging to the user
+func (s *AuthService) RevokeAllSessions(userID uint) error {
+	ctx := context.Background()
+	userKey := userFamiliesKey(userID)
+
+	families, err := s.redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, family := range families {
+		revokeTokenFamily(ctx, s.redis, family)
+	}
+
+	return s.redis.Del(ctx, userKey).Err()
+}
+
+// UnlockAccount lifts a login lockout on the user's account
+func (s *AuthService) UnlockAccount(userID uint) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	return s.loginGuard.Unlock(user.Email)
+}
+
+// SetUserActive activates or deactivates an account. Deactivation also revokes all live sessions.
+func (s *AuthService) SetUserActive(userID uint, active bool) error {
+	result := s.db.Model(&models.User{}).Where("id = ?", userID).Update("active", active)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	if !active {
+		return s.RevokeAllSessions(userID)
+	}
+	return nil
+}
+
+// RevokeRefreshToken revokes the family the given refresh token belongs to
+func (s *AuthService) RevokeRefreshToken(refreshToken string) error {
+	ctx := context.Background()
+	family, err := s.redis.HGet(ctx, "refresh:"+utils.HashToken(refreshToken), "family").Result()
+	if err == redis.Nil {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+
+	revokeTokenFamily(ctx, s.redis, family)
+	return nil
+}
+
+// issueTokenPair creates an access token with its session and a refresh token, both tracked under
+// the family. created is true for the first pair of a family.
+func (s *AuthService) issueTokenPair(user models.User, family string, mfa bool, device Device, created bool) (*TokenPair, error) {
+	accessTTL := config.AppConfig.AccessTokenTTL
+	refreshTTL := config.AppConfig.RefreshTokenTTL
+
+	accessToken, err := s.GenerateJWT(user, mfa, family)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	sessionKey := "session:" + accessToken
+	refreshKey := "refresh:" + utils.HashToken(refreshToken)
+	familyKey := "refresh_family:" + family
+	userKey := userFamiliesKey(user.ID)
+
+	pipe := s.redis.TxPipeline()
+	// Store token in Redis for session management
+	pipe.Set(ctx, sessionKey, user.ID, accessTTL)
+	pipe.HSet(ctx, refreshKey, "user_id", user.ID, "family", family, "mfa", mfa, "issued_at", time.Now().Unix())
+	pipe.Expire(ctx, refreshKey, refreshTTL)
+	pipe.SAdd(ctx, familyKey, sessionKey, refreshKey)
+	pipe.Expire(ctx, familyKey, refreshTTL)
+	pipe.SAdd(ctx, userKey, family)
+	pipe.Expire(ctx, userKey, refreshTTL)
+	recordSession(ctx, pipe, family, user.ID, device, mfa, created)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTTL.Seconds()),
+	}, nil
+}
+
+// userFamiliesKey is the Redis set tracking every token family issued to a user
+func userFamiliesKey(userID uint) string {
+	return "user_sessions:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// RequestPasswordReset emails a single-use reset link. Unknown or inactive emails are ignored
+// so the endpoint cannot be used to discover accounts.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	var user models.User
+	if err := s.db.Where("email = ? AND active = ?", email, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	ttl := config.AppConfig.PasswordResetTTL
+	tokenKey := "password_reset:" + utils.HashToken(token)
+	userKey := "password_reset_user:" + strconv.FormatUint(uint64(user.ID), 10)
+
+	// Only the most recently requested token stays valid
+	if previous, err := s.redis.Get(ctx, userKey).Result(); err == nil {
+		s.redis.Del(ctx, previous)
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, tokenKey, user.ID, ttl)
+	pipe.Set(ctx, userKey, tokenKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	link := config.AppConfig.FrontendURL + "/reset-password?token=" + token
+	return s.mailer.Send(Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "Hi " + user.FirstName + ",\n\n" +
+			"We received a request to reset your password. Use the link below to choose a new one:\n\n" +
+			link + "\n\n" +
+			"The link expires in " + ttl.String() + " and can only be used once. " +
+			"If you did not request a password reset you can ignore this email.\n",
+	})
+}
+
+// ResetPassword consumes a reset token, sets the new password and ends every existing session.
+// It returns the ID of the user whose password was reset.
+func (s *AuthService) ResetPassword(token, newPassword string) (uint, error) {
+	// Check the policy first so a weak password does not use up the token
+	if err := s.policy.Validate(newPassword); err != nil {
+		return 0, err
+	}
+
+	ctx := context.Background()
+
+	// GETDEL makes the token single-use even under concurrent requests
+	value, err := s.redis.GetDel(ctx, "password_reset:"+utils.HashToken(token)).Result()
+	if err == redis.Nil {
+		return 0, ErrInvalidResetToken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	userID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidResetToken
+	}
+	s.redis.Del(ctx, "password_reset_user:"+value)
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	result := s.db.Model(&models.User{}).Where("id = ? AND active = ?", userID, true).Update("password", hashedPassword)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrInvalidResetToken
+	}
+
+	return uint(userID), s.RevokeAllSessions(uint(userID))
+}
+
+// ChangePassword replaces the password after checking the current one. Every existing session
+// is ended, so callers should hand the user a fresh token pair.
+func (s *AuthService) ChangePassword(userID uint, currentPassword, newPassword string) (*models.User, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, _ := utils.VerifyPassword(user.Password, currentPassword); !ok {
+		return nil, ErrInvalidPassword
+	}
+
+	if err := s.policy.Validate(newPassword); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(user).Update("password", hashedPassword).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.RevokeAllSessions(userID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// rehashPassword stores a fresh argon2id hash. Failures are only logged because the login itself succeeded.
+func (s *AuthService) rehashPassword(userID uint, password string) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		log.Printf("Error rehashing password for user %d: %v", userID, err)
+		return
+	}
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Update("password", hashedPassword).Error; err != nil {
+		log.Printf("Error storing rehashed password for user %d: %v", userID, err)
+	}
+}
+
+// SendVerificationEmail emails a signed link that confirms the user's email address
+func (s *AuthService) SendVerificationEmail(user models.User) error {
+	ttl := config.AppConfig.EmailVerificationTTL
+	claims := jwt.MapClaims{
+		"sub":     strconv.FormatUint(uint64(user.ID), 10),
+		"email":   user.Email,
+		"purpose": "verify_email",
+		"exp":     time.Now().Add(ttl).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.AppConfig.JWT_SECRET))
+	if err != nil {
+		return err
+	}
+
+	link := config.AppConfig.FrontendURL + "/verify-email?token=" + token
+	return s.mailer.Send(Mail{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: "Hi " + user.FirstName + ",\n\n" +
+			"Please confirm your email address by opening the link below:\n\n" +
+			link + "\n\n" +
+			"The link expires in " + ttl.String() + ".\n",
+	})
+}
+
+// ResendVerificationEmail sends a new verification link. Requests are throttled per email address,
+// whether or not an account exists, so the endpoint does not reveal registered emails.
+func (s *AuthService) ResendVerificationEmail(email string) error {
+	ctx := context.Background()
+	interval := config.AppConfig.VerificationResendInterval
+	throttleKey := "verify_email_resend:" + utils.HashToken(strings.ToLower(email))
+
+	allowed, err := s.redis.SetNX(ctx, throttleKey, 1, interval).Result()
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		retryAfter, err := s.redis.TTL(ctx, throttleKey).Result()
+		if err != nil || retryAfter < 0 {
+			retryAfter = interval
+		}
+		return &ThrottledError{RetryAfter: retryAfter}
+	}
+
+	var user models.User
+	if err := s.db.Where("email = ? AND active = ?", email, true).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	return s.SendVerificationEmail(user)
+}
+
+// VerifyEmail checks a verification link and marks the address as verified.
+// The link is bound to the email it was sent to, so it stops working if the email changes.
+func (s *AuthService) VerifyEmail(tokenString string) (*models.User, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWT_SECRET), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "verify_email" {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	subject, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	userID, err := strconv.ParseUint(subject, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	var user models.User
+	if err := s.db.Where("id = ? AND email = ?", userID, email).First(&user).Error; err != nil {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	if !user.EmailVerified {
+		now := time.Now()
+		if err := s.db.Model(&user).Updates(map[string]interface{}{
+			"email_verified":    true,
+			"email_verified_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+
+	return &user, nil
+}
+
 func (s *AuthService) GetUserByID(userID uint) (*models.User, error) {
 	var user models.User
 	if err := s.db.First(&user, userID).Error; err != nil {
@@ -92,7 +602,7 @@ func (s *AuthService) GetUserByID(userID uint) (*models.User, error) {
 	return &user, nil
 }
 
-func (s *AuthService) UpdateProfile(userID uint, firstName, lastName, phone, address, city, country, postalCode string) error {
+func (s *AuthService) UpdateProfile(userID uint, firstName, lastName, phone string) error {
 	// Update user basic info
 	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
 		"first_name": firstName,
@@ -101,41 +611,50 @@ func (s *AuthService) UpdateProfile(userID uint, firstName, lastName, phone, add
 		return err
 	}
 
-	// Update or create profile
+	// Update or create profile. Addresses live in the address book.
+	// Save goes through the encrypting serializer, which map updates would bypass.
 	var profile models.UserProfile
-	result := s.db.Where("user_id = ?", userID).First(&profile)
-	if result.Error != nil {
-		// Create new profile
-		profile = models.UserProfile{
-			UserID:     userID,
-			Phone:      phone,
-			Address:    address,
-			City:       city,
-			Country:    country,
-			PostalCode: postalCode,
+	if err := s.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
 		}
-		return s.db.Create(&profile).Error
-	} else {
-		// Update existing profile
-		return s.db.Model(&profile).Updates(map[string]interface{}{
-			"phone":       phone,
-			"address":     address,
-			"city":        city,
-			"country":     country,
-			"postal_code": postalCode,
-		}).Error
+		profile = models.UserProfile{UserID: userID}
 	}
+
+	profile.Phone = phone
+	return s.db.Save(&profile).Error
 }
 
-func (s *AuthService) GenerateJWT(user models.User) (string, error) {
-	claims := jwt.MapClaims{
+// GenerateJWT signs an access token with the current asymmetric key. The mfa claim tells
+// whether a second factor was used and sid names the session the token belongs to.
+func (s *AuthService) GenerateJWT(user models.User, mfa bool, sessionID string) (string, error) {
+	claims, err := accessTokenClaims(user, mfa, sessionID, config.AppConfig.AccessTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	return GetJWTKeys().Sign(claims)
+}
+
+// accessTokenClaims builds the claims of an access token for the user that expires after ttl
+func accessTokenClaims(user models.User, mfa bool, sessionID string, ttl time.Duration) (jwt.MapClaims, error) {
+	// A unique jti keeps tokens issued in the same second apart, since the token is the session key
+	jti, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":     config.AppConfig.JWTIssuer,
+		"aud":     config.AppConfig.JWTAudience,
+		"sub":     strconv.FormatUint(uint64(user.ID), 10),
+		"jti":     jti,
+		"sid":     sessionID,
 		"user_id": user.ID,
 		"email":   user.Email,
 		"role":    user.Role,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-		"iat":     time.Now().Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.AppConfig.JWT_SECRET))
+		"mfa":     mfa,
+		"exp":     now.Add(ttl).Unix(),
+		"iat":     now.Unix(),
+	}, nil
 }
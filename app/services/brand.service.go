@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+var (
+	ErrInvalidBrandName = errors.New("brand name must contain a letter or digit")
+	ErrBrandExists      = errors.New("a brand with this name or spelling already exists")
+	ErrBrandAliasTaken  = errors.New("this spelling already belongs to a brand")
+	ErrBrandAliasIsName = errors.New("the alias of the brand's own name cannot be removed")
+)
+
+// brandProductCountSelect selects every brand column and the number of active products of the brand
+const brandProductCountSelect = `brands.*, (
+	SELECT COUNT(*) FROM products
+	WHERE products.brand_id = brands.id AND products.active = true AND products.deleted_at IS NULL
+) AS product_count`
+
+type BrandService struct {
+	db             *gorm.DB
+	redis          *redis.Client
+	productService *ProductService
+}
+
+func NewBrandService() *BrandService {
+	return &BrandService{
+		db:             database.DB,
+		redis:          database.Redis,
+		productService: NewProductService(),
+	}
+}
+
+// GetBrands returns the active brands by name with their product counts
+func (s *BrandService) GetBrands() ([]models.Brand, error) {
+	cacheKey := "brands"
+
+	ctx := context.Background()
+	cached, err := s.redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var brands []models.Brand
+		if json.Unmarshal([]byte(cached), &brands) == nil {
+			return brands, nil
+		}
+	}
+
+	var brands []models.Brand
+	err = s.db.Select(brandProductCountSelect).Where("active = ?", true).Order("name").Find(&brands).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache for 30 minutes, like the category list
+	if data, err := json.Marshal(brands); err == nil {
+		s.redis.Set(ctx, cacheKey, data, 30*time.Minute)
+	}
+
+	return brands, nil
+}
+
+// GetBrand returns an active brand with its product count
+func (s *BrandService) GetBrand(id uint) (*models.Brand, error) {
+	var brand models.Brand
+	err := s.db.Select(brandProductCountSelect).Where("active = ?", true).First(&brand, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &brand, nil
+}
+
+// GetAdminBrands returns every brand, active or not, with its aliases and product count
+func (s *BrandService) GetAdminBrands() ([]models.Brand, error) {
+	var brands []models.Brand
+	err := s.db.Select(brandProductCountSelect).
+		Preload("Aliases", func(db *gorm.DB) *gorm.DB { return db.Order("alias") }).
+		Order("name").
+		Find(&brands).Error
+	return brands, err
+}
+
+// CreateBrand adds a brand. Its name is normalised and recorded as its first alias.
+func (s *BrandService) CreateBrand(request dto.CreateBrandRequest) (*models.Brand, error) {
+	name := utils.NormalizeBrandName(request.Name)
+	key := utils.BrandKey(name)
+	if key == "" {
+		return nil, ErrInvalidBrandName
+	}
+	if request.Logo != "" && !ValidImageFile(request.Logo) {
+		return nil, ErrInvalidImageFile
+	}
+
+	brand := models.Brand{
+		Name:        name,
+		Logo:        request.Logo,
+		Description: request.Description,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.BrandAlias{}).Where("alias = ? OR key = ?", name, key).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrBrandExists
+		}
+
+		slug, err := uniqueSlug(tx, &models.Brand{}, name, "brand")
+		if err != nil {
+			return err
+		}
+		brand.Slug = slug
+
+		if err := tx.Omit("Aliases").Create(&brand).Error; err != nil {
+			return err
+		}
+		// The column default turns a false Active into true on insert
+		if request.Active != nil && !*request.Active {
+			if err := tx.Model(&brand).Update("active", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.BrandAlias{BrandID: brand.ID, Alias: name, Key: key}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clearBrandCaches(s.redis)
+
+	return s.getAdminBrand(brand.ID)
+}
+
+// UpdateBrand changes the fields of a brand that are set. A new name is recorded as an alias and
+// copied to the brand name stored on its products.
+func (s *BrandService) UpdateBrand(id uint, request dto.UpdateBrandRequest) (*models.Brand, error) {
+	if request.Logo != nil && *request.Logo != "" && !ValidImageFile(*request.Logo) {
+		return nil, ErrInvalidImageFile
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var brand models.Brand
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&brand, id).Error; err != nil {
+			return err
+		}
+
+		renamed := false
+		if request.Name != nil {
+			name := utils.NormalizeBrandName(*request.Name)
+			key := utils.BrandKey(name)
+			if key == "" {
+				return ErrInvalidBrandName
+			}
+			if name != brand.Name {
+				if err := addBrandAlias(tx, brand.ID, name); err != nil {
+					return err
+				}
+				brand.Name = name
+				renamed = true
+			}
+		}
+		if request.Logo != nil {
+			brand.Logo = *request.Logo
+		}
+		if request.Description != nil {
+			brand.Description = *request.Description
+		}
+		if request.Active != nil {
+			brand.Active = *request.Active
+		}
+
+		if err := tx.Model(&brand).Select("name", "logo", "description", "active").Updates(&brand).Error; err != nil {
+			return err
+		}
+
+		if renamed {
+			return tx.Unscoped().Model(&models.Product{}).
+				Where("brand_id = ?", brand.ID).
+				Update("brand", brand.Name).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached products include their brand
+	clearBrandCaches(s.redis)
+	s.productService.clearCatalogCaches()
+
+	return s.getAdminBrand(id)
+}
+
+// AddAlias records another spelling of a brand, such as "HP" for "Hewlett-Packard". Products
+// created or uploaded with that spelling then get the brand.
+func (s *BrandService) AddAlias(brandID uint, alias string) (*models.Brand, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.Brand{}, brandID).Error; err != nil {
+			return err
+		}
+		return addBrandAlias(tx, brandID, alias)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getAdminBrand(brandID)
+}
+
+// RemoveAlias deletes a spelling of a brand. Products that already have the brand keep it.
+func (s *BrandService) RemoveAlias(brandID, aliasID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var brand models.Brand
+		if err := tx.First(&brand, brandID).Error; err != nil {
+			return err
+		}
+
+		var alias models.BrandAlias
+		if err := tx.Where("id = ? AND brand_id = ?", aliasID, brandID).First(&alias).Error; err != nil {
+			return err
+		}
+		if alias.Alias == brand.Name {
+			return ErrBrandAliasIsName
+		}
+
+		return tx.Delete(&alias).Error
+	})
+}
+
+func (s *BrandService) getAdminBrand(id uint) (*models.Brand, error) {
+	var brand models.Brand
+	err := s.db.Select(brandProductCountSelect).
+		Preload("Aliases", func(db *gorm.DB) *gorm.DB { return db.Order("alias") }).
+		First(&brand, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &brand, nil
+}
+
+// addBrandAlias records a spelling for a brand. A spelling whose key belongs to another brand is
+// refused, because lookups by key would then be ambiguous.
+func addBrandAlias(tx *gorm.DB, brandID uint, alias string) error {
+	alias = utils.NormalizeBrandName(alias)
+	key := utils.BrandKey(alias)
+	if key == "" {
+		return ErrInvalidBrandName
+	}
+
+	var existing []models.BrandAlias
+	if err := tx.Where("alias = ? OR key = ?", alias, key).Find(&existing).Error; err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.Alias == alias {
+			if other.BrandID == brandID {
+				return nil
+			}
+			return ErrBrandAliasTaken
+		}
+		if other.BrandID != brandID {
+			return ErrBrandAliasTaken
+		}
+	}
+
+	return tx.Create(&models.BrandAlias{BrandID: brandID, Alias: alias, Key: key}).Error
+}
+
+// resolveBrand finds the brand for a brand name as written on a product: by the exact spelling,
+// then by its normalised key. An unknown brand is created, and a new spelling of a known brand is
+// recorded as an alias. An empty name has no brand.
+func resolveBrand(tx *gorm.DB, raw string) (*models.Brand, error) {
+	name := utils.NormalizeBrandName(raw)
+	key := utils.BrandKey(name)
+	if key == "" {
+		return nil, nil
+	}
+
+	var alias models.BrandAlias
+	err := tx.Where("alias = ?", name).First(&alias).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Where("key = ?", key).Order("id").First(&alias).Error
+		if err == nil {
+			newAlias := models.BrandAlias{BrandID: alias.BrandID, Alias: name, Key: key}
+			err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&newAlias).Error
+		}
+	}
+	switch {
+	case err == nil:
+		var brand models.Brand
+		if err := tx.First(&brand, alias.BrandID).Error; err != nil {
+			return nil, err
+		}
+		return &brand, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	slug, err := uniqueSlug(tx, &models.Brand{}, name, "brand")
+	if err != nil {
+		return nil, err
+	}
+	brand := models.Brand{Name: name, Slug: slug, Active: true}
+	if err := tx.Omit("Aliases").Create(&brand).Error; err != nil {
+		return nil, fmt.Errorf("failed to create brand '%s': %w", name, err)
+	}
+	if err := tx.Create(&models.BrandAlias{BrandID: brand.ID, Alias: name, Key: key}).Error; err != nil {
+		return nil, fmt.Errorf("failed to create brand '%s': %w", name, err)
+	}
+	return &brand, nil
+}
+
+// setProductBrand points a product at the brand for its brand name and stores the brand's name
+func setProductBrand(tx *gorm.DB, product *models.Product) error {
+	brand, err := resolveBrand(tx, product.Brand)
+	if err != nil {
+		return err
+	}
+	if brand == nil {
+		product.Brand = ""
+		product.BrandID = nil
+		return nil
+	}
+	product.Brand = brand.Name
+	product.BrandID = &brand.ID
+	return nil
+}
+
+// clearBrandCaches clears the cached brand list
+func clearBrandCaches(client *redis.Client) {
+	client.Del(context.Background(), "brands")
+}
@@ -0,0 +1,583 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+)
+
+var (
+	ErrInvalidCategoryName   = errors.New("category name must not be empty or contain \">\"")
+	ErrCategoryExists        = errors.New("a category with this name already exists below the same parent")
+	ErrCategoryParentInvalid = errors.New("parent must be an existing category other than the category itself or one of its subcategories")
+	ErrCategoryNotEmpty      = errors.New("category still has products or subcategories; merge it into another category instead")
+	ErrCategoryMergeInvalid  = errors.New("a category can only be merged into another category outside its own subtree")
+	ErrCategoryMergeConflict = errors.New("both categories have a subcategory with the same name")
+)
+
+// categoryPathSeparator separates the levels of a category path such as "Electronics > Audio > Headphones"
+const categoryPathSeparator = ">"
+
+// activeCategoryDescendantsSQL selects a category and its active descendants. UNION drops rows
+// that were already visited, so the recursion also ends if the tree ever contains a cycle.
+const activeCategoryDescendantsSQL = `
+	WITH RECURSIVE tree AS (
+		SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+		UNION
+		SELECT c.id FROM categories c JOIN tree ON c.parent_id = tree.id
+		WHERE c.deleted_at IS NULL AND c.active = true
+	)
+	SELECT id FROM tree`
+
+// categoryDescendantsSQL selects the IDs of a category's subcategories at any depth, active or not
+const categoryDescendantsSQL = `
+	WITH RECURSIVE tree AS (
+		SELECT id FROM categories WHERE parent_id = ? AND deleted_at IS NULL
+		UNION
+		SELECT c.id FROM categories c JOIN tree ON c.parent_id = tree.id
+		WHERE c.deleted_at IS NULL
+	)
+	SELECT id FROM tree`
+
+// categoryProductCountSelect selects every category column and the number of active products in the category
+const categoryProductCountSelect = `categories.*, (
+	SELECT COUNT(*) FROM products
+	WHERE products.category_id = categories.id AND products.active = true AND products.deleted_at IS NULL
+) AS product_count`
+
+type CategoryService struct {
+	db             *gorm.DB
+	redis          *redis.Client
+	productService *ProductService
+}
+
+func NewCategoryService() *CategoryService {
+	return &CategoryService{
+		db:             database.DB,
+		redis:          database.Redis,
+		productService: NewProductService(),
+	}
+}
+
+// CategoryMergeResult reports what a merge moved into the target category
+type CategoryMergeResult struct {
+	Category           *models.Category
+	ProductsMoved      int64
+	SubcategoriesMoved int64
+}
+
+// GetAdminCategories returns every category, active or not, with its product count
+func (s *CategoryService) GetAdminCategories() ([]models.Category, error) {
+	var categories []models.Category
+	err := s.db.Select(categoryProductCountSelect).Order("name, id").Find(&categories).Error
+	return categories, err
+}
+
+// GetCategory returns a category with its product count
+func (s *CategoryService) GetCategory(id uint) (*models.Category, error) {
+	var category models.Category
+	if err := s.db.Select(categoryProductCountSelect).First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// CreateCategory adds a category at the root of the tree or below an existing parent
+func (s *CategoryService) CreateCategory(request dto.CreateCategoryRequest) (*models.Category, error) {
+	name := strings.TrimSpace(request.Name)
+	if !validCategoryName(name) {
+		return nil, ErrInvalidCategoryName
+	}
+
+	category := models.Category{
+		ParentID:    categoryParentID(request.ParentID),
+		Name:        name,
+		Description: strings.TrimSpace(request.Description),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if category.ParentID != nil {
+			if err := tx.Select("id").First(&models.Category{}, *category.ParentID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrCategoryParentInvalid
+				}
+				return err
+			}
+		}
+		if err := ensureCategoryNameAvailable(tx, category.ParentID, name, 0); err != nil {
+			return err
+		}
+
+		slug, err := uniqueSlug(tx, &models.Category{}, name, "category")
+		if err != nil {
+			return err
+		}
+		category.Slug = slug
+
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		// The column default turns a false Active into true on insert
+		if request.Active != nil && !*request.Active {
+			return tx.Model(&category).Update("active", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clearCategoryCaches(s.redis)
+
+	return s.GetCategory(category.ID)
+}
+
+// UpdateCategory renames, describes or moves a category. A rename is copied to the category name
+// stored on its products.
+func (s *CategoryService) UpdateCategory(id uint, request dto.UpdateCategoryRequest) (*models.Category, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, id).Error; err != nil {
+			return err
+		}
+
+		renamed := false
+		if request.Name != nil {
+			name := strings.TrimSpace(*request.Name)
+			if !validCategoryName(name) {
+				return ErrInvalidCategoryName
+			}
+			renamed = name != category.Name
+			category.Name = name
+		}
+		if request.Description != nil {
+			category.Description = strings.TrimSpace(*request.Description)
+		}
+		if request.ParentID != nil {
+			category.ParentID = categoryParentID(request.ParentID)
+			if err := validateCategoryParent(tx, category.ID, category.ParentID); err != nil {
+				return err
+			}
+		}
+
+		if err := ensureCategoryNameAvailable(tx, category.ParentID, category.Name, category.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&category).Select("parent_id", "name", "description").Updates(&category).Error; err != nil {
+			return err
+		}
+
+		if renamed {
+			return tx.Unscoped().Model(&models.Product{}).
+				Where("category_id = ?", category.ID).
+				Update("category", category.Name).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached products include their category and breadcrumbs
+	clearCategoryCaches(s.redis)
+	s.productService.clearCatalogCaches()
+
+	return s.GetCategory(id)
+}
+
+// SetCategoryActive activates or deactivates a category. Products keep their category; an
+// inactive category and its subcategories are hidden from the public category listings.
+func (s *CategoryService) SetCategoryActive(id uint, active bool) (*models.Category, error) {
+	result := s.db.Model(&models.Category{}).Where("id = ?", id).Update("active", active)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	clearCategoryCaches(s.redis)
+	s.productService.clearCatalogCaches()
+
+	return s.GetCategory(id)
+}
+
+// DeleteCategory removes an empty category together with its attribute definitions.
+// Categories that still have products or subcategories have to be merged instead.
+func (s *CategoryService) DeleteCategory(id uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, id).Error; err != nil {
+			return err
+		}
+
+		var products, children int64
+		if err := tx.Model(&models.Product{}).Where("category_id = ?", id).Count(&products).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if products > 0 || children > 0 {
+			return ErrCategoryNotEmpty
+		}
+
+		if err := deleteCategoryAttributes(tx, id); err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	clearCategoryCaches(s.redis)
+
+	return nil
+}
+
+// MergeCategory moves everything in the source category into the target and deletes the source.
+// Products get the target's ID and name, subcategories move below the target, and product
+// attribute values are kept where the target has an attribute with the same key and type.
+// Everything happens in one transaction.
+func (s *CategoryService) MergeCategory(sourceID, targetID uint) (*CategoryMergeResult, error) {
+	if sourceID == targetID {
+		return nil, ErrCategoryMergeInvalid
+	}
+
+	result := &CategoryMergeResult{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var source, target models.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, targetID).Error; err != nil {
+			return err
+		}
+
+		// The target must not end up below itself
+		var descendants []uint
+		if err := tx.Raw(categoryDescendantsSQL, sourceID).Scan(&descendants).Error; err != nil {
+			return err
+		}
+		for _, id := range descendants {
+			if id == targetID {
+				return ErrCategoryMergeInvalid
+			}
+		}
+
+		var conflicts []string
+		err := tx.Model(&models.Category{}).
+			Where("parent_id = ? AND name IN (?)", sourceID,
+				tx.Model(&models.Category{}).Select("name").Where("parent_id = ?", targetID)).
+			Pluck("name", &conflicts).Error
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return fmt.Errorf("%w: %s", ErrCategoryMergeConflict, strings.Join(conflicts, ", "))
+		}
+
+		// Keep attribute values the target category also defines
+		err = tx.Exec(`
+			UPDATE product_attribute_values pav SET attribute_id = target.id, updated_at = NOW()
+			FROM attribute_definitions source
+			JOIN attribute_definitions target ON target.category_id = ? AND target.key = source.key AND target.type = source.type
+			WHERE pav.attribute_id = source.id AND source.category_id = ?
+		`, targetID, sourceID).Error
+		if err != nil {
+			return err
+		}
+		if err := deleteCategoryAttributes(tx, sourceID); err != nil {
+			return err
+		}
+
+		products := tx.Unscoped().Model(&models.Product{}).
+			Where("category_id = ?", sourceID).
+			Updates(map[string]interface{}{"category_id": targetID, "category": target.Name})
+		if products.Error != nil {
+			return products.Error
+		}
+		result.ProductsMoved = products.RowsAffected
+
+		children := tx.Model(&models.Category{}).Where("parent_id = ?", sourceID).Update("parent_id", targetID)
+		if children.Error != nil {
+			return children.Error
+		}
+		result.SubcategoriesMoved = children.RowsAffected
+
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clearCategoryCaches(s.redis)
+	s.productService.clearCatalogCaches()
+
+	result.Category, err = s.GetCategory(targetID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetCategoryTree returns the active root categories with their active descendants nested in
+// Children. A category below an inactive parent is hidden together with its parent.
+func (s *CategoryService) GetCategoryTree() ([]models.Category, error) {
+	cacheKey := "categories:tree"
+
+	ctx := context.Background()
+	cached, err := s.redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var tree []models.Category
+		if json.Unmarshal([]byte(cached), &tree) == nil {
+			return tree, nil
+		}
+	}
+
+	var categories []models.Category
+	err = s.db.Select(categoryProductCountSelect).
+		Where("active = ?", true).
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tree := buildCategoryTree(categories)
+
+	// Cache for 30 minutes, like the flat category list
+	if data, err := json.Marshal(tree); err == nil {
+		s.redis.Set(ctx, cacheKey, data, 30*time.Minute)
+	}
+
+	return tree, nil
+}
+
+// buildCategoryTree nests categories below their parents, sorted by name on every level
+func buildCategoryTree(categories []models.Category) []models.Category {
+	children := make(map[uint][]models.Category)
+	for _, category := range categories {
+		var parentID uint
+		if category.ParentID != nil {
+			parentID = *category.ParentID
+		}
+		children[parentID] = append(children[parentID], category)
+	}
+
+	var build func(parentID uint, visited map[uint]bool) []models.Category
+	build = func(parentID uint, visited map[uint]bool) []models.Category {
+		nodes := children[parentID]
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].Name < nodes[j].Name
+		})
+
+		tree := make([]models.Category, 0, len(nodes))
+		for _, node := range nodes {
+			if visited[node.ID] {
+				continue
+			}
+			visited[node.ID] = true
+			node.Children = build(node.ID, visited)
+			tree = append(tree, node)
+		}
+		return tree
+	}
+
+	return build(0, make(map[uint]bool))
+}
+
+// validCategoryName reports whether name can be used for a category. ">" separates the levels
+// of category paths in bulk uploads, so it cannot be part of a name.
+func validCategoryName(name string) bool {
+	return name != "" && len(name) <= 100 && !strings.Contains(name, categoryPathSeparator)
+}
+
+// categoryParentID turns a requested parent ID into the stored one; 0 means the root
+func categoryParentID(parentID *uint) *uint {
+	if parentID == nil || *parentID == 0 {
+		return nil
+	}
+	id := *parentID
+	return &id
+}
+
+// ensureCategoryNameAvailable fails when another category below the same parent has the name
+func ensureCategoryNameAvailable(tx *gorm.DB, parentID *uint, name string, excludeID uint) error {
+	query := tx.Model(&models.Category{}).Where("name = ? AND id <> ?", name, excludeID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryExists
+	}
+	return nil
+}
+
+// validateCategoryParent checks that a category can move below parentID without creating a cycle
+func validateCategoryParent(tx *gorm.DB, id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == id {
+		return ErrCategoryParentInvalid
+	}
+
+	if err := tx.Select("id").First(&models.Category{}, *parentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCategoryParentInvalid
+		}
+		return err
+	}
+
+	var descendants []uint
+	if err := tx.Raw(categoryDescendantsSQL, id).Scan(&descendants).Error; err != nil {
+		return err
+	}
+	for _, descendant := range descendants {
+		if descendant == *parentID {
+			return ErrCategoryParentInvalid
+		}
+	}
+	return nil
+}
+
+// uniqueSlug builds a slug from a name, adding a counter when the model's table already has it.
+// Soft-deleted rows keep their slug, so they are checked too.
+func uniqueSlug(tx *gorm.DB, model interface{}, name, fallback string) (string, error) {
+	base := strings.ReplaceAll(attributeKey(name), "_", "-")
+	if base == "" {
+		base = fallback
+	}
+
+	slug := base
+	for counter := 1; ; counter++ {
+		var count int64
+		if err := tx.Unscoped().Model(model).Where("slug = ?", slug).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, counter)
+	}
+}
+
+// deleteCategoryAttributes removes the attribute definitions of a category and their product values
+func deleteCategoryAttributes(tx *gorm.DB, categoryID uint) error {
+	definitions := tx.Model(&models.AttributeDefinition{}).Select("id").Where("category_id = ?", categoryID)
+	if err := tx.Where("attribute_id IN (?)", definitions).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("category_id = ?", categoryID).Delete(&models.AttributeDefinition{}).Error
+}
+
+// categoryPath splits a category path such as "Electronics > Audio > Headphones" into its
+// names from the root down. A plain name is a path with one level.
+func categoryPath(raw string) []string {
+	var path []string
+	for _, name := range strings.Split(raw, categoryPathSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			path = append(path, name)
+		}
+	}
+	return path
+}
+
+// activeCategoryDescendants returns a subquery with the IDs of a category and its active descendants
+func activeCategoryDescendants(db *gorm.DB, categoryID uint) *gorm.DB {
+	return db.Raw(activeCategoryDescendantsSQL, categoryID)
+}
+
+// categoryBreadcrumbs returns the path from the root down to each of the given categories,
+// the category itself included
+func categoryBreadcrumbs(db *gorm.DB, categoryIDs []uint) (map[uint][]models.Category, error) {
+	breadcrumbs := make(map[uint][]models.Category)
+	if len(categoryIDs) == 0 {
+		return breadcrumbs, nil
+	}
+
+	var rows []struct {
+		LeafID   uint
+		ID       uint
+		ParentID *uint
+		Name     string
+		Slug     string
+		Active   bool
+	}
+	err := db.Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id AS leaf_id, id, parent_id, name, slug, active, 0 AS depth, ARRAY[id] AS visited
+			FROM categories WHERE id IN ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT chain.leaf_id, c.id, c.parent_id, c.name, c.slug, c.active, chain.depth + 1, chain.visited || c.id
+			FROM categories c JOIN chain ON c.id = chain.parent_id
+			WHERE c.deleted_at IS NULL AND NOT c.id = ANY(chain.visited)
+		)
+		SELECT leaf_id, id, parent_id, name, slug, active FROM chain ORDER BY leaf_id, depth DESC
+	`, categoryIDs).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		breadcrumbs[row.LeafID] = append(breadcrumbs[row.LeafID], models.Category{
+			ID:       row.ID,
+			ParentID: row.ParentID,
+			Name:     row.Name,
+			Slug:     row.Slug,
+			Active:   row.Active,
+		})
+	}
+	return breadcrumbs, nil
+}
+
+// attachBreadcrumbs sets the category breadcrumbs of each product
+func attachBreadcrumbs(db *gorm.DB, products []models.Product) error {
+	seen := make(map[uint]bool)
+	var categoryIDs []uint
+	for _, product := range products {
+		if product.CategoryID != 0 && !seen[product.CategoryID] {
+			seen[product.CategoryID] = true
+			categoryIDs = append(categoryIDs, product.CategoryID)
+		}
+	}
+
+	breadcrumbs, err := categoryBreadcrumbs(db, categoryIDs)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].Breadcrumbs = breadcrumbs[products[i].CategoryID]
+	}
+	return nil
+}
+
+// clearCategoryCaches clears the cached category list and tree
+func clearCategoryCaches(client *redis.Client) {
+	ctx := context.Background()
+	client.Del(ctx, "categories")
+	iter := client.Scan(ctx, 0, "categories:*", 1000).Iterator()
+	for iter.Next(ctx) {
+		client.Del(ctx, iter.Val())
+	}
+}
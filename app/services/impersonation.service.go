@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+var (
+	ErrImpersonateSelf           = errors.New("you cannot impersonate yourself")
+	ErrImpersonateServiceAccount = errors.New("service accounts cannot be impersonated")
+	ErrImpersonatePrivileged     = errors.New("users with admin permissions cannot be impersonated")
+	ErrImpersonationEnded        = errors.New("impersonation session has already ended")
+)
+
+func init() {
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "impersonation_sessions",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			var sessions []models.ImpersonationSession
+			err := tx.Where("user_id = ?", userID).Order("started_at DESC").Find(&sessions).Error
+			return sessions, err
+		},
+		// The sessions are the audit trail of admins acting on the account, so they are kept
+		// without the device details
+		Erase: func(tx *gorm.DB, userID uint) error {
+			return tx.Model(&models.ImpersonationSession{}).
+				Where("user_id = ?", userID).
+				Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error
+		},
+	})
+}
+
+// Impersonation is a started impersonation session with its access token
+type Impersonation struct {
+	Session   models.ImpersonationSession
+	Token     string
+	ExpiresIn int64
+}
+
+// ImpersonationRecord is an impersonation session with the email addresses of both accounts
+type ImpersonationRecord struct {
+	models.ImpersonationSession
+	AdminEmail string
+	UserEmail  string
+}
+
+// ImpersonationFilter narrows down the impersonation session list
+type ImpersonationFilter struct {
+	AdminID    *uint
+	UserID     *uint
+	ActiveOnly bool
+	Page       int
+	Limit      int
+}
+
+type ImpersonationService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	roleService *RoleService
+}
+
+func NewImpersonationService() *ImpersonationService {
+	return &ImpersonationService{
+		db:          database.DB,
+		redis:       database.Redis,
+		roleService: NewRoleService(),
+	}
+}
+
+// Start issues a short-lived access token for the target user that also names the admin. The
+// token cannot be refreshed and ends after IMPERSONATION_TTL. Only regular accounts without any
+// admin permission can be impersonated, so impersonation never grants more access than the admin has.
+func (s *ImpersonationService) Start(adminID, userID uint, reason string, device Device) (*Impersonation, error) {
+	if adminID == userID {
+		return nil, ErrImpersonateSelf
+	}
+
+	var user models.User
+	if err := s.db.Where("id = ? AND active = ?", userID, true).First(&user).Error; err != nil {
+		return nil, err
+	}
+	if user.ServiceAccount {
+		return nil, ErrImpersonateServiceAccount
+	}
+
+	permissions, err := s.roleService.GetPermissionsForRole(user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) > 0 {
+		return nil, ErrImpersonatePrivileged
+	}
+
+	family, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := config.AppConfig.ImpersonationTTL
+	claims, err := accessTokenClaims(user, false, family, ttl)
+	if err != nil {
+		return nil, err
+	}
+	claims["impersonator_id"] = adminID
+	token, err := GetJWTKeys().Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.ImpersonationSession{
+		AdminID:   adminID,
+		UserID:    user.ID,
+		SessionID: family,
+		Reason:    reason,
+		IP:        device.IP,
+		UserAgent: device.UserAgent,
+		StartedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.db.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	sessionKey := "session:" + token
+	familyKey := "refresh_family:" + family
+	metaKey := sessionMetaKey(family)
+
+	// The session is tracked under the user like any other, so revoking the user's sessions ends it
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, sessionKey, user.ID, ttl)
+	pipe.SAdd(ctx, familyKey, sessionKey)
+	pipe.Expire(ctx, familyKey, ttl)
+	pipe.SAdd(ctx, userFamiliesKey(user.ID), family)
+	pipe.Expire(ctx, userFamiliesKey(user.ID), config.AppConfig.RefreshTokenTTL)
+	recordSession(ctx, pipe, family, user.ID, device, false, true)
+	pipe.HSet(ctx, metaKey, "impersonator_id", adminID)
+	pipe.Expire(ctx, metaKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.db.Delete(&session)
+		return nil, err
+	}
+
+	return &Impersonation{
+		Session:   session,
+		Token:     token,
+		ExpiresIn: int64(ttl.Seconds()),
+	}, nil
+}
+
+// End revokes the token of an impersonation session and marks it as ended
+func (s *ImpersonationService) End(id uint) (*models.ImpersonationSession, error) {
+	var session models.ImpersonationSession
+	if err := s.db.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	if !session.Active() {
+		return nil, ErrImpersonationEnded
+	}
+
+	return &session, s.end(&session)
+}
+
+// EndBySessionID ends the impersonation session behind a token family, if there is one
+func (s *ImpersonationService) EndBySessionID(sessionID string) (*models.ImpersonationSession, error) {
+	var session models.ImpersonationSession
+	if err := s.db.Where("session_id = ? AND ended_at IS NULL", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+
+	return &session, s.end(&session)
+}
+
+func (s *ImpersonationService) end(session *models.ImpersonationSession) error {
+	ctx := context.Background()
+	revokeTokenFamily(ctx, s.redis, session.SessionID)
+	s.redis.SRem(ctx, userFamiliesKey(session.UserID), session.SessionID)
+
+	now := time.Now()
+	session.EndedAt = &now
+	return s.db.Model(session).Update("ended_at", now).Error
+}
+
+// GetSessions returns a page of impersonation sessions matching the filter, newest first
+func (s *ImpersonationService) GetSessions(filter ImpersonationFilter) ([]ImpersonationRecord, int64, error) {
+	query := s.db.Table("impersonation_sessions AS i")
+
+	if filter.AdminID != nil {
+		query = query.Where("i.admin_id = ?", *filter.AdminID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("i.user_id = ?", *filter.UserID)
+	}
+	if filter.ActiveOnly {
+		query = query.Where("i.ended_at IS NULL AND i.expires_at > ?", time.Now())
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	var records []ImpersonationRecord
+	err := query.
+		Select("i.*, a.email AS admin_email, u.email AS user_email").
+		Joins("LEFT JOIN users a ON a.id = i.admin_id").
+		Joins("LEFT JOIN users u ON u.id = i.user_id").
+		Order("i.started_at DESC, i.id DESC").
+		Offset(offset).Limit(filter.Limit).
+		Scan(&records).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return records, total, nil
+}
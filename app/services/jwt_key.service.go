@@ -0,0 +1,393 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+)
+
+const (
+	// A new key is published in the JWKS this long before it is used for signing,
+	// so verifiers that cache the JWKS pick it up first
+	jwtKeyPublishDelay = 10 * time.Minute
+	// JWKSCacheMaxAge is how long verifiers may cache the JWKS response
+	JWKSCacheMaxAge = 5 * time.Minute
+
+	jwtKeyReloadInterval = time.Minute
+	rsaKeyBits           = 3072
+)
+
+var (
+	ErrUnknownSigningKey = errors.New("unknown signing key")
+	ErrNoSigningKey      = errors.New("no JWT signing key available")
+)
+
+// SigningKey is a private key loaded from JWT_KEYS_DIR. The file name without
+// the .pem extension is the key ID.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWTKeyService signs and verifies access tokens with asymmetric keys kept on disk
+type JWTKeyService struct {
+	mu      sync.RWMutex
+	dir     string
+	keys    map[string]*SigningKey
+	signing *SigningKey
+}
+
+var jwtKeys *JWTKeyService
+
+// InitJWTKeys loads the signing keys, creates one when the directory is empty and
+// starts the background reload and rotation loop. It must run before tokens are issued.
+func InitJWTKeys() error {
+	service := &JWTKeyService{dir: config.AppConfig.JWTKeysDir}
+
+	if err := os.MkdirAll(service.dir, 0o700); err != nil {
+		return err
+	}
+	if err := service.reload(); err != nil {
+		return err
+	}
+	if len(service.keys) == 0 {
+		log.Printf("No JWT signing keys found in %s, generating a new %s key", service.dir, config.AppConfig.JWTKeyAlgorithm)
+		if err := service.generate(); err != nil {
+			return err
+		}
+	}
+
+	jwtKeys = service
+	go service.maintain()
+
+	return nil
+}
+
+// GetJWTKeys returns the key service set up by InitJWTKeys
+func GetJWTKeys() *JWTKeyService {
+	return jwtKeys
+}
+
+// Sign signs the claims with the current signing key and sets the kid header
+func (s *JWTKeyService) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	key := s.signing
+	s.mu.RUnlock()
+
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.Private)
+}
+
+// ParseAccessToken verifies the signature, algorithm, kid, issuer, audience and expiry of an access token
+func (s *JWTKeyService) ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, s.keyfunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(config.AppConfig.JWTIssuer),
+		jwt.WithAudience(config.AppConfig.JWTAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
+
+// JWKS returns the public part of every loaded key
+func (s *JWTKeyService) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range s.sortedKeys() {
+		jwk := JWK{
+			Use:       "sig",
+			KeyID:     key.ID,
+			Algorithm: key.Algorithm,
+		}
+
+		switch public := key.Private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// keyfunc picks the verification key by kid and rejects tokens whose alg does not match the key
+func (s *JWTKeyService) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	return key.Private.Public(), nil
+}
+
+// maintain periodically picks up keys added by other instances and rotates keys when due
+func (s *JWTKeyService) maintain() {
+	ticker := time.NewTicker(jwtKeyReloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.reload(); err != nil {
+			log.Printf("Error reloading JWT keys: %v", err)
+			continue
+		}
+
+		s.mu.RLock()
+		keys := s.sortedKeys()
+		s.mu.RUnlock()
+
+		if len(keys) == 0 {
+			log.Printf("Warning: all JWT signing keys were removed from %s, generating a new one", s.dir)
+			if err := s.generate(); err != nil {
+				log.Printf("Error generating JWT signing key: %v", err)
+			}
+			continue
+		}
+
+		interval := config.AppConfig.JWTKeyRotationInterval
+		if interval <= 0 {
+			continue
+		}
+
+		if newest := keys[0]; time.Since(newest.CreatedAt) >= interval {
+			log.Printf("Rotating JWT signing key %s", newest.ID)
+			if err := s.generate(); err != nil {
+				log.Printf("Error rotating JWT signing key: %v", err)
+				continue
+			}
+		}
+
+		s.prune()
+	}
+}
+
+// reload reads every *.pem file in the key directory and selects the signing key
+func (s *JWTKeyService) reload() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*SigningKey, len(paths))
+	for _, path := range paths {
+		key, err := loadSigningKey(path)
+		if err != nil {
+			log.Printf("Warning: skipping JWT key %s: %v", path, err)
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = keys
+	s.signing = s.selectSigningKey()
+
+	return nil
+}
+
+// generate writes a new key with the configured algorithm and reloads the key set
+func (s *JWTKeyService) generate() error {
+	var private crypto.Signer
+	var err error
+
+	switch config.AppConfig.JWTKeyAlgorithm {
+	case jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return fmt.Errorf("unsupported JWT key algorithm %q, use RS256 or EdDSA", config.AppConfig.JWTKeyAlgorithm)
+	}
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+
+	suffix, err := randomHex(4)
+	if err != nil {
+		return err
+	}
+	kid := time.Now().UTC().Format("20060102T150405Z") + "-" + suffix
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(s.dir, kid+".pem"), data, 0o600); err != nil {
+		return err
+	}
+
+	return s.reload()
+}
+
+// prune deletes keys that stopped signing long enough ago that no token signed with them is still valid
+func (s *JWTKeyService) prune() {
+	s.mu.RLock()
+	keys := s.sortedKeys()
+	s.mu.RUnlock()
+
+	// keys[i-1] replaced keys[i] once it was published
+	for i := 1; i < len(keys); i++ {
+		retiredAt := keys[i-1].CreatedAt.Add(jwtKeyPublishDelay)
+		if time.Since(retiredAt) <= config.AppConfig.AccessTokenTTL {
+			continue
+		}
+
+		log.Printf("Removing retired JWT signing key %s", keys[i].ID)
+		if err := os.Remove(filepath.Join(s.dir, keys[i].ID+".pem")); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing JWT key %s: %v", keys[i].ID, err)
+		}
+	}
+
+	if err := s.reload(); err != nil {
+		log.Printf("Error reloading JWT keys: %v", err)
+	}
+}
+
+// selectSigningKey returns the newest key that has been published long enough, falling
+// back to the newest key on a fresh start. Callers must hold the lock.
+func (s *JWTKeyService) selectSigningKey() *SigningKey {
+	keys := s.sortedKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+
+	for _, key := range keys {
+		if time.Since(key.CreatedAt) >= jwtKeyPublishDelay {
+			return key
+		}
+	}
+	return keys[0]
+}
+
+// sortedKeys returns the keys newest first. Callers must hold the lock.
+func (s *JWTKeyService) sortedKeys() []*SigningKey {
+	keys := make([]*SigningKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// loadSigningKey parses a PKCS#8 (RSA or Ed25519) or PKCS#1 (RSA) PEM private key
+func loadSigningKey(path string) (*SigningKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{
+		ID:        strings.TrimSuffix(filepath.Base(path), ".pem"),
+		CreatedAt: info.ModTime(),
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm = jwt.SigningMethodRS256.Alg()
+		key.Private = private
+	case ed25519.PrivateKey:
+		key.Algorithm = jwt.SigningMethodEdDSA.Alg()
+		key.Private = private
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+)
+
+// Mail is a plain text email message
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional emails
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+// NewMailer returns the mailer selected by MAIL_DRIVER
+func NewMailer() Mailer {
+	switch config.AppConfig.MailDriver {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     config.AppConfig.SMTPHost,
+			Port:     config.AppConfig.SMTPPort,
+			Username: config.AppConfig.SMTPUsername,
+			Password: config.AppConfig.SMTPPassword,
+			From:     config.AppConfig.MailFrom,
+		}
+	default:
+		return &LogMailer{
+			Path: config.AppConfig.MailLogFile,
+			From: config.AppConfig.MailFrom,
+		}
+	}
+}
+
+// LogMailer writes emails to a file, or to stdout when no path is set. Meant for local development.
+type LogMailer struct {
+	Path string
+	From string
+
+	mu sync.Mutex
+}
+
+func (m *LogMailer) Send(mail Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out io.Writer = os.Stdout
+	if m.Path != "" {
+		file, err := os.OpenFile(m.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	_, err := out.Write(buildMessage(m.From, mail))
+	if err == nil {
+		_, err = io.WriteString(out, "\n")
+	}
+	return err
+}
+
+// SMTPMailer sends emails through an SMTP server. Credentials are optional so a local SMTP sink can be used.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(mail Mail) error {
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, m.From, []string{mail.To}, buildMessage(m.From, mail)); err != nil {
+		log.Printf("Error sending email to %s: %v", mail.To, err)
+		return err
+	}
+	return nil
+}
+
+// buildMessage renders an RFC 5322 message with CRLF line endings
+func buildMessage(from string, mail Mail) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+const (
+	recoveryCodeCount   = 10
+	maxMFALoginAttempts = 5
+	mfaEnrolmentTTL     = 10 * time.Minute
+	mfaLastStepTTL      = 2 * time.Minute
+)
+
+// acceptTOTPStepScript records a TOTP time step as used when it is newer than the last one,
+// and returns 1 if it was accepted
+var acceptTOTPStepScript = redis.NewScript(`
+local last = redis.call("GET", KEYS[1])
+if last and tonumber(ARGV[1]) <= tonumber(last) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "EX", ARGV[2])
+return 1
+`)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFANoEnrolment    = errors.New("no pending two-factor enrolment, start again")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+	ErrInvalidMFAToken   = errors.New("invalid or expired two-factor login token")
+	ErrMFARequiredByRole = errors.New("two-factor authentication is mandatory for your role")
+)
+
+// MFAChallenge is returned by Login when the password was correct but a second factor is still needed
+type MFAChallenge struct {
+	Token     string
+	ExpiresIn int64
+}
+
+func (e *MFAChallenge) Error() string {
+	return "two-factor authentication required"
+}
+
+// MFAEnrolment holds the secret an authenticator app must be set up with
+type MFAEnrolment struct {
+	Secret     string
+	OTPAuthURI string
+}
+
+type MFAService struct {
+	db         *gorm.DB
+	redis      *redis.Client
+	loginGuard *LoginGuard
+}
+
+func NewMFAService() *MFAService {
+	return &MFAService{
+		db:         database.DB,
+		redis:      database.Redis,
+		loginGuard: NewLoginGuard(),
+	}
+}
+
+// StartEnrolment generates a new secret. It only becomes active once confirmed with a valid code.
+func (s *MFAService) StartEnrolment(user models.User) (*MFAEnrolment, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.redis.Set(context.Background(), enrolmentKey(user.ID), secret, mfaEnrolmentTTL).Err(); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrolment{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(config.AppConfig.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrolment enables 2FA when the code matches the pending secret and returns fresh recovery codes
+func (s *MFAService) ConfirmEnrolment(user models.User, code string) ([]string, error) {
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	ctx := context.Background()
+	secret, err := s.redis.Get(ctx, enrolmentKey(user.ID)).Result()
+	if err == redis.Nil {
+		return nil, ErrMFANoEnrolment
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, strings.TrimSpace(code), time.Now(), 1)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// A struct update, so the secret goes through the encrypted serializer
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Select("mfa_enabled", "mfa_secret").Updates(models.User{
+			MFAEnabled: true,
+			MFASecret:  secret,
+		}).Error; err != nil {
+			return err
+		}
+
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.redis.Del(ctx, enrolmentKey(user.ID))
+	s.redis.Set(ctx, lastStepKey(user.ID), step, mfaLastStepTTL)
+
+	return codes, nil
+}
+
+// Disable turns 2FA off after checking a current code. Roles that require 2FA cannot disable it.
+func (s *MFAService) Disable(user models.User, code string) error {
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if config.AppConfig.MFARequiredForRole(user.Role) {
+		return ErrMFARequiredByRole
+	}
+
+	ok, err := s.VerifyCode(user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"mfa_enabled": false,
+			"mfa_secret":  "",
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes invalidates the old recovery codes after checking a current code
+func (s *MFAService) RegenerateRecoveryCodes(user models.User, code string) ([]string, error) {
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	ok, err := s.VerifyCode(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// VerifyCode accepts either a TOTP code or an unused recovery code. TOTP codes cannot be replayed
+// and recovery codes are consumed on use.
+func (s *MFAService) VerifyCode(user models.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if code == "" || !user.MFAEnabled {
+		return false, nil
+	}
+
+	ctx := context.Background()
+	if step, ok := utils.ValidateTOTP(user.MFASecret, code, time.Now(), 1); ok {
+		// Only accept each time step once. The check and the update are one script, so two
+		// requests with the same code cannot both pass, and a Redis error fails closed.
+		accepted, err := acceptTOTPStepScript.Run(ctx, s.redis, []string{lastStepKey(user.ID)}, step, int(mfaLastStepTTL.Seconds())).Int()
+		if err != nil {
+			return false, err
+		}
+		return accepted == 1, nil
+	}
+
+	now := time.Now()
+	result := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CreateLoginChallenge stores a short-lived mfa_pending token for a user who passed the password check
+func (s *MFAService) CreateLoginChallenge(user models.User) (*MFAChallenge, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	ttl := config.AppConfig.MFAPendingTTL
+	key := "mfa_pending:" + utils.HashToken(token)
+
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", user.ID, "attempts", 0)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return &MFAChallenge{
+		Token:     token,
+		ExpiresIn: int64(ttl.Seconds()),
+	}, nil
+}
+
+// CompleteLoginChallenge checks the code for an mfa_pending token. The token is consumed on success
+// and after too many wrong codes. Wrong codes count as failed logins of the account, so fresh
+// challenges cannot be used to guess codes past the login lockout.
+func (s *MFAService) CompleteLoginChallenge(token, code, ip string) (*models.User, error) {
+	ctx := context.Background()
+	key := "mfa_pending:" + utils.HashToken(token)
+
+	userIDValue, err := s.redis.HGet(ctx, key, "user_id").Result()
+	if err == redis.Nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.redis.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if attempts > maxMFALoginAttempts {
+		s.redis.Del(ctx, key)
+		return nil, ErrInvalidMFAToken
+	}
+
+	userID, err := strconv.ParseUint(userIDValue, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	var user models.User
+	if err := s.db.Where("id = ? AND active = ?", userID, true).First(&user).Error; err != nil {
+		s.redis.Del(ctx, key)
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := s.loginGuard.Check(user.Email, ip); err != nil {
+		return &user, err
+	}
+
+	ok, err := s.VerifyCode(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.loginGuard.RecordFailure(user.Email, ip)
+		return &user, ErrInvalidMFACode
+	}
+
+	// A pending token can only be exchanged once
+	if deleted, err := s.redis.Del(ctx, key).Result(); err != nil || deleted == 0 {
+		return nil, ErrInvalidMFAToken
+	}
+
+	s.loginGuard.RecordSuccess(user.Email)
+
+	return &user, nil
+}
+
+// replaceRecoveryCodes deletes existing recovery codes and stores a new set, returning the plain codes
+func (s *MFAService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		records[i] = models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(raw),
+		}
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode lets users type recovery codes with or without the dash
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
+
+func enrolmentKey(userID uint) string {
+	return "mfa_enrolment:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+func lastStepKey(userID uint) string {
+	return "mfa_last_step:" + strconv.FormatUint(uint64(userID), 10)
+}
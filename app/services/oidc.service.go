@@ -0,0 +1,416 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+// OIDCStateCookie ties a login to the browser that started it. It holds a hash of the state.
+const OIDCStateCookie = "oidc_state"
+
+var (
+	ErrUnknownOIDCProvider  = errors.New("unknown identity provider")
+	ErrInvalidOIDCState     = errors.New("invalid or expired login state, start the login again")
+	ErrOIDCEmailNotVerified = errors.New("the identity provider did not return a verified email address")
+	ErrOIDCNoAccount        = errors.New("no account exists for this identity")
+	ErrOIDCAccountDisabled  = errors.New("account is disabled")
+	ErrOIDCLinkNotAllowed   = errors.New("this account has admin permissions and cannot be linked to the identity provider by email")
+)
+
+// oidcClaims are the ID token claims used for provisioning. Groups are read separately
+// because the claim name is configurable.
+type oidcClaims struct {
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	GivenName     string   `json:"given_name"`
+	FamilyName    string   `json:"family_name"`
+	Name          string   `json:"name"`
+	AMR           []string `json:"amr"`
+}
+
+// OIDCLogin is a completed single sign-on. ReturnTo is the dashboard page to go back to when
+// the login was started from the admin login page, and empty for API logins.
+type OIDCLogin struct {
+	User     *models.User
+	MFA      bool
+	ReturnTo string
+}
+
+type OIDCService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	authService *AuthService
+	mfa         *MFAService
+
+	mu        sync.Mutex
+	providers map[string]*oidc.Provider
+}
+
+func NewOIDCService() *OIDCService {
+	return &OIDCService{
+		db:          database.DB,
+		redis:       database.Redis,
+		authService: NewAuthService(),
+		mfa:         NewMFAService(),
+		providers:   map[string]*oidc.Provider{},
+	}
+}
+
+// StartLogin returns the provider's authorization URL and the value for the OIDCStateCookie of
+// the browser. The state, nonce and PKCE verifier are kept in Redis until the callback, together
+// with returnTo, the dashboard page of a login started from the admin login page.
+func (s *OIDCService) StartLogin(providerName, returnTo string) (string, string, error) {
+	cfg, ok := config.AppConfig.OIDCProvider(providerName)
+	if !ok {
+		return "", "", ErrUnknownOIDCProvider
+	}
+
+	oauthConfig, _, err := s.oauthConfig(cfg)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	ctx := context.Background()
+	key := "oidc_state:" + utils.HashToken(state)
+
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, key, "provider", cfg.Name, "nonce", nonce, "verifier", verifier, "return_to", returnTo)
+	pipe.Expire(ctx, key, config.AppConfig.OIDCStateTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", "", err
+	}
+
+	authURL := oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	return authURL, utils.HashToken(state), nil
+}
+
+// CompleteLogin exchanges the authorization code, verifies the ID token and returns the linked
+// user without starting a session. stateCookie is the OIDCStateCookie of the browser, which must
+// belong to the state, so a callback URL cannot finish a login in another browser. Like Login, it
+// returns an *MFAChallenge when local 2FA still has to be completed. Once the state has been
+// read, the login is returned with errors as well, so the caller knows where it started.
+func (s *OIDCService) CompleteLogin(providerName, code, state, stateCookie string) (*OIDCLogin, error) {
+	cfg, ok := config.AppConfig.OIDCProvider(providerName)
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+
+	stateHash := utils.HashToken(state)
+	if subtle.ConstantTimeCompare([]byte(stateCookie), []byte(stateHash)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+
+	ctx := context.Background()
+	key := "oidc_state:" + stateHash
+
+	// The state is single-use, so read and delete it in one transaction
+	pipe := s.redis.TxPipeline()
+	stored := pipe.HGetAll(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	values := stored.Val()
+	if len(values) == 0 || values["provider"] != cfg.Name {
+		return nil, ErrInvalidOIDCState
+	}
+	login := &OIDCLogin{ReturnTo: values["return_to"]}
+
+	oauthConfig, provider, err := s.oauthConfig(cfg)
+	if err != nil {
+		return login, err
+	}
+
+	exchangeCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	token, err := oauthConfig.Exchange(exchangeCtx, code, oauth2.VerifierOption(values["verifier"]))
+	if err != nil {
+		return login, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return login, errors.New("identity provider did not return an ID token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}).Verify(exchangeCtx, rawIDToken)
+	if err != nil {
+		return login, err
+	}
+	if idToken.Nonce != values["nonce"] {
+		return login, ErrInvalidOIDCState
+	}
+
+	var claims oidcClaims
+	var allClaims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return login, err
+	}
+	if err := idToken.Claims(&allClaims); err != nil {
+		return login, err
+	}
+
+	user, err := s.resolveUser(cfg, idToken.Subject, claims, groupsFromClaims(allClaims[cfg.GroupsClaim]))
+	if err != nil {
+		return login, err
+	}
+	login.User = user
+
+	// Accept the provider's second factor, otherwise fall back to local 2FA when it is enabled
+	login.MFA = slices.Contains(claims.AMR, "mfa")
+	if user.MFAEnabled && !login.MFA {
+		challenge, err := s.mfa.CreateLoginChallenge(*user)
+		if err != nil {
+			return login, err
+		}
+		return login, challenge
+	}
+
+	return login, nil
+}
+
+// resolveUser finds the user linked to the identity, links an existing user with the same
+// verified email, or provisions a new one. The role is synced from the IdP groups when a
+// role mapping is configured; a changed role revokes the user's existing sessions.
+func (s *OIDCService) resolveUser(cfg config.OIDCProvider, subject string, claims oidcClaims, groups []string) (*models.User, error) {
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	now := time.Now()
+	var user models.User
+	roleChanged := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", cfg.Name, subject).First(&identity).Error
+		switch {
+		case err == nil:
+			if err := tx.First(&user, identity.UserID).Error; err != nil {
+				return ErrOIDCNoAccount
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			identity = models.UserIdentity{Provider: cfg.Name, Subject: subject}
+
+			err := tx.Where("LOWER(email) = ?", email).First(&user).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if !cfg.AutoProvision {
+					return ErrOIDCNoAccount
+				}
+				if err := s.provisionUser(tx, cfg, email, claims, &user); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			} else if !cfg.TrustedLinking {
+				// Taking over a privileged account only needs control of its email at the
+				// provider, so such accounts are only linked for trusted providers
+				privileged, err := roleHasPermissions(tx, user.Role)
+				if err != nil {
+					return err
+				}
+				if privileged {
+					return ErrOIDCLinkNotAllowed
+				}
+			}
+			identity.UserID = user.ID
+		default:
+			return err
+		}
+
+		if user.ServiceAccount || !user.Active {
+			return ErrOIDCAccountDisabled
+		}
+
+		identity.Email = email
+		identity.LastLoginAt = &now
+		if err := tx.Save(&identity).Error; err != nil {
+			return err
+		}
+
+		// The IdP has verified the address, so the local account counts as verified too
+		updates := map[string]interface{}{}
+		if !user.EmailVerified {
+			updates["email_verified"] = true
+			updates["email_verified_at"] = now
+		}
+		if len(cfg.RoleMapping) > 0 {
+			role, mapped := mapGroupsToRole(cfg, groups)
+			if (mapped || cfg.DemoteUnmapped) && role != user.Role {
+				updates["role"] = role
+				roleChanged = true
+			}
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&user).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens issued before carry the old role, so a role change ends the user's sessions
+	if roleChanged {
+		if err := s.authService.RevokeAllSessions(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+func (s *OIDCService) provisionUser(tx *gorm.DB, cfg config.OIDCProvider, email string, claims oidcClaims, user *models.User) error {
+	// SSO users never log in with a password, so store an unguessable one
+	password, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(claims.Name, " ")
+	}
+	if firstName == "" {
+		firstName = email
+	}
+
+	now := time.Now()
+	*user = models.User{
+		Email:           email,
+		Password:        hashedPassword,
+		FirstName:       firstName,
+		LastName:        lastName,
+		Role:            cfg.DefaultRole,
+		Active:          true,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+
+	log.Printf("Provisioned user %s from OIDC provider %s", email, cfg.Name)
+	return nil
+}
+
+// oauthConfig discovers the provider on first use and caches it, so the API can start
+// while the provider is unreachable
+func (s *OIDCService) oauthConfig(cfg config.OIDCProvider) (*oauth2.Config, *oidc.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	provider, ok := s.providers[cfg.Name]
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var err error
+		provider, err = oidc.NewProvider(ctx, cfg.IssuerURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.providers[cfg.Name] = provider
+	}
+
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       cfg.Scopes,
+	}, provider, nil
+}
+
+// mapGroupsToRole returns the role of the first mapping whose group the user belongs to. When
+// no mapping matches, it returns the default role and false.
+func mapGroupsToRole(cfg config.OIDCProvider, groups []string) (string, bool) {
+	for _, mapping := range cfg.RoleMapping {
+		if slices.Contains(groups, mapping.Group) {
+			return mapping.Role, true
+		}
+	}
+	return cfg.DefaultRole, false
+}
+
+// roleHasPermissions reports whether the role has been granted any permission
+func roleHasPermissions(tx *gorm.DB, role string) (bool, error) {
+	var count int64
+	err := tx.Table("role_permissions").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ?", role).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ValidateOIDCRoles checks that the default and mapped roles of every configured provider
+// exist, so a typo cannot lock SSO users out or leave them with an unknown role
+func ValidateOIDCRoles() error {
+	for _, provider := range config.AppConfig.OIDCProviders {
+		roles := []string{provider.DefaultRole}
+		for _, mapping := range provider.RoleMapping {
+			roles = append(roles, mapping.Role)
+		}
+
+		for _, role := range roles {
+			var count int64
+			if err := database.DB.Model(&models.Role{}).Where("name = ?", role).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("OIDC provider %s uses role %q, which does not exist", provider.Name, role)
+			}
+		}
+	}
+	return nil
+}
+
+// groupsFromClaims accepts a groups claim sent as a list or a single string
+func groupsFromClaims(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, item := range v {
+			if group, ok := item.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	}
+	return nil
+}
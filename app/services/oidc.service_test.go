@@ -0,0 +1,64 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+)
+
+func TestMapGroupsToRole(t *testing.T) {
+	cfg := config.OIDCProvider{
+		DefaultRole: "user",
+		RoleMapping: []config.OIDCRoleMapping{
+			{Group: "platform-admins", Role: "admin"},
+			{Group: "editors", Role: "editor"},
+			{Group: "staff", Role: "user"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		groups     []string
+		wantRole   string
+		wantMapped bool
+	}{
+		{"single match", []string{"editors"}, "editor", true},
+		{"first mapping wins", []string{"staff", "editors", "platform-admins"}, "admin", true},
+		{"mapped to the default role", []string{"staff"}, "user", true},
+		{"no matching group", []string{"contractors"}, "user", false},
+		{"no groups", nil, "user", false},
+		{"groups are case sensitive", []string{"Platform-Admins"}, "user", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, mapped := mapGroupsToRole(cfg, tt.groups)
+			if role != tt.wantRole || mapped != tt.wantMapped {
+				t.Errorf("mapGroupsToRole(%v) = %q, %v, want %q, %v", tt.groups, role, mapped, tt.wantRole, tt.wantMapped)
+			}
+		})
+	}
+}
+
+func TestGroupsFromClaims(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{"list", []interface{}{"staff", "editors"}, []string{"staff", "editors"}},
+		{"single string", "staff", []string{"staff"}},
+		{"non-string items are skipped", []interface{}{"staff", 42, nil, "editors"}, []string{"staff", "editors"}},
+		{"empty list", []interface{}{}, []string{}},
+		{"missing claim", nil, nil},
+		{"unsupported type", map[string]interface{}{"staff": true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupsFromClaims(tt.value)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("groupsFromClaims(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+)
+
+// PasswordPolicyError lists every rule a password failed
+type PasswordPolicyError struct {
+	Problems []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the policy: " + strings.Join(e.Problems, "; ")
+}
+
+var (
+	breachedPasswords     map[string]struct{}
+	breachedPasswordsOnce sync.Once
+)
+
+type PasswordPolicy struct {
+	minLength     int
+	maxLength     int
+	requireUpper  bool
+	requireLower  bool
+	requireDigit  bool
+	requireSymbol bool
+	breached      map[string]struct{}
+}
+
+func NewPasswordPolicy() *PasswordPolicy {
+	cfg := config.AppConfig
+
+	return &PasswordPolicy{
+		minLength:     cfg.PasswordMinLength,
+		maxLength:     cfg.PasswordMaxLength,
+		requireUpper:  cfg.PasswordRequireUpper,
+		requireLower:  cfg.PasswordRequireLower,
+		requireDigit:  cfg.PasswordRequireDigit,
+		requireSymbol: cfg.PasswordRequireSymbol,
+		breached:      loadBreachedPasswords(cfg.PasswordBreachedListFile),
+	}
+}
+
+// Validate returns a *PasswordPolicyError when the password breaks any rule
+func (p *PasswordPolicy) Validate(password string) error {
+	var problems []string
+
+	length := len([]rune(password))
+	if length < p.minLength {
+		problems = append(problems, "must be at least "+strconv.Itoa(p.minLength)+" characters")
+	}
+	if p.maxLength > 0 && length > p.maxLength {
+		problems = append(problems, "must be at most "+strconv.Itoa(p.maxLength)+" characters")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.requireUpper && !hasUpper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if p.requireLower && !hasLower {
+		problems = append(problems, "must contain a lowercase letter")
+	}
+	if p.requireDigit && !hasDigit {
+		problems = append(problems, "must contain a digit")
+	}
+	if p.requireSymbol && !hasSymbol {
+		problems = append(problems, "must contain a symbol")
+	}
+
+	if _, found := p.breached[strings.ToLower(password)]; found {
+		problems = append(problems, "appears in a list of breached passwords")
+	}
+
+	if len(problems) > 0 {
+		return &PasswordPolicyError{Problems: problems}
+	}
+	return nil
+}
+
+// loadBreachedPasswords reads the breached password list once per process. Entries are
+// compared case-insensitively.
+func loadBreachedPasswords(path string) map[string]struct{} {
+	breachedPasswordsOnce.Do(func() {
+		breachedPasswords = map[string]struct{}{}
+		if path == "" {
+			return
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			log.Printf("Warning: could not open breached password list %s: %v", path, err)
+			return
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				breachedPasswords[strings.ToLower(line)] = struct{}{}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Printf("Warning: failed to read breached password list %s: %v", path, err)
+		}
+
+		log.Printf("Loaded %d breached passwords from %s", len(breachedPasswords), path)
+	})
+
+	return breachedPasswords
+}
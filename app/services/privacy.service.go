@@ -0,0 +1,359 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+// accountPurgeLockKey makes sure only one instance purges accounts at a time
+const accountPurgeLockKey = "account_purge_lock"
+
+var ErrDeletionAlreadyRequested = errors.New("account deletion has already been requested")
+
+// PersonalDataSection is one part of a user's personal data. Export adds it to the data
+// archive and Erase removes it when the account is purged. Features that store personal
+// data (orders, reviews, ...) register a section so exports and deletions stay complete.
+type PersonalDataSection struct {
+	Name   string
+	Export func(tx *gorm.DB, userID uint) (interface{}, error)
+	Erase  func(tx *gorm.DB, userID uint) error
+}
+
+var personalDataSections []PersonalDataSection
+
+// RegisterPersonalDataSection adds a section to every data export and account purge
+func RegisterPersonalDataSection(section PersonalDataSection) {
+	personalDataSections = append(personalDataSections, section)
+}
+
+func init() {
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "account",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			var user models.User
+			err := tx.First(&user, userID).Error
+			return user, err
+		},
+	})
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "profile",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			var profiles []models.UserProfile
+			err := tx.Where("user_id = ?", userID).Find(&profiles).Error
+			return profiles, err
+		},
+		Erase: func(tx *gorm.DB, userID uint) error {
+			return tx.Unscoped().Where("user_id = ?", userID).Delete(&models.UserProfile{}).Error
+		},
+	})
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "identities",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			var identities []models.UserIdentity
+			err := tx.Where("user_id = ?", userID).Find(&identities).Error
+			return identities, err
+		},
+		Erase: func(tx *gorm.DB, userID uint) error {
+			return tx.Where("user_id = ?", userID).Delete(&models.UserIdentity{}).Error
+		},
+	})
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "recovery_codes",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			var codes []models.RecoveryCode
+			err := tx.Where("user_id = ?", userID).Find(&codes).Error
+			return codes, err
+		},
+		Erase: func(tx *gorm.DB, userID uint) error {
+			return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+		},
+	})
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "sessions",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			return NewSessionService().GetSessions(userID)
+		},
+	})
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "deletion_requests",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			var deletions []models.AccountDeletion
+			err := tx.Where("user_id = ?", userID).Order("requested_at").Find(&deletions).Error
+			return deletions, err
+		},
+	})
+}
+
+// DataExport holds every section of a user's personal data, keyed by section name
+type DataExport struct {
+	UserID      uint                   `json:"user_id"`
+	GeneratedAt time.Time              `json:"generated_at"`
+	Sections    map[string]interface{} `json:"sections"`
+}
+
+type PrivacyService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	authService *AuthService
+	mailer      Mailer
+}
+
+func NewPrivacyService() *PrivacyService {
+	return &PrivacyService{
+		db:          database.DB,
+		redis:       database.Redis,
+		authService: NewAuthService(),
+		mailer:      NewMailer(),
+	}
+}
+
+// ExportData collects the personal data of a user from every registered section
+func (s *PrivacyService) ExportData(userID uint) (*DataExport, error) {
+	export := &DataExport{
+		UserID:      userID,
+		GeneratedAt: time.Now(),
+		Sections:    make(map[string]interface{}, len(personalDataSections)),
+	}
+
+	for _, section := range personalDataSections {
+		data, err := section.Export(s.db, userID)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", section.Name, err)
+		}
+		export.Sections[section.Name] = data
+	}
+
+	return export, nil
+}
+
+// ExportArchive returns the export as a ZIP archive with one JSON file per section
+func (s *PrivacyService) ExportArchive(userID uint) ([]byte, error) {
+	export, err := s.ExportData(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	writeJSON := func(name string, value interface{}) error {
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: export.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	names := make([]string, 0, len(personalDataSections))
+	for _, section := range personalDataSections {
+		if err := writeJSON(section.Name+".json", export.Sections[section.Name]); err != nil {
+			return nil, err
+		}
+		names = append(names, section.Name)
+	}
+
+	manifest := map[string]interface{}{
+		"user_id":      export.UserID,
+		"generated_at": export.GeneratedAt,
+		"sections":     names,
+	}
+	if err := writeJSON("manifest.json", manifest); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RequestDeletion schedules the account for deletion after the grace period. The account is
+// deactivated right away; reactivating it before the purge cancels the request.
+func (s *PrivacyService) RequestDeletion(userID uint, password, reason string) (*models.AccountDeletion, error) {
+	user, err := s.authService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, _ := utils.VerifyPassword(user.Password, password); !ok {
+		return nil, ErrInvalidPassword
+	}
+
+	var pending int64
+	if err := s.db.Model(&models.AccountDeletion{}).
+		Where("user_id = ? AND completed_at IS NULL AND cancelled_at IS NULL", userID).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, ErrDeletionAlreadyRequested
+	}
+
+	now := time.Now()
+	deletion := models.AccountDeletion{
+		UserID:       userID,
+		EmailHash:    utils.HashToken(strings.ToLower(user.Email)),
+		Method:       accountDeletionMethod(),
+		Reason:       reason,
+		RequestedAt:  now,
+		ScheduledFor: now.Add(config.AppConfig.AccountDeletionGracePeriod),
+	}
+	if err := s.db.Create(&deletion).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.authService.SetUserActive(userID, false); err != nil {
+		return nil, err
+	}
+
+	if err := s.mailer.Send(Mail{
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to delete your account. It has been deactivated and will be permanently deleted on %s.\n\nIf you did not request this or changed your mind, contact support before then to keep your account.\n",
+			user.FirstName, deletion.ScheduledFor.Format("2 January 2006")),
+	}); err != nil {
+		log.Printf("Failed to send deletion notice to user %d: %v", userID, err)
+	}
+
+	return &deletion, nil
+}
+
+// PurgeDueAccounts completes every deletion whose grace period has passed and returns how
+// many accounts were purged
+func (s *PrivacyService) PurgeDueAccounts() (int, error) {
+	ctx := context.Background()
+
+	locked, err := s.redis.SetNX(ctx, accountPurgeLockKey, 1, config.AppConfig.AccountPurgeInterval).Result()
+	if err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer s.redis.Del(ctx, accountPurgeLockKey)
+
+	var due []models.AccountDeletion
+	if err := s.db.Where("scheduled_for <= ? AND completed_at IS NULL AND cancelled_at IS NULL", time.Now()).
+		Order("scheduled_for").Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, deletion := range due {
+		if err := s.purge(deletion); err != nil {
+			log.Printf("Failed to purge account %d: %v", deletion.UserID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purge erases the user's personal data and marks the deletion as completed
+func (s *PrivacyService) purge(deletion models.AccountDeletion) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, section := range personalDataSections {
+			if section.Erase == nil {
+				continue
+			}
+			if err := section.Erase(tx, deletion.UserID); err != nil {
+				return fmt.Errorf("erase %s: %w", section.Name, err)
+			}
+		}
+
+		if deletion.Method == models.AccountDeletionDelete {
+			if err := tx.Unscoped().Delete(&models.User{}, deletion.UserID).Error; err != nil {
+				return err
+			}
+		} else if err := anonymiseUser(tx, deletion.UserID); err != nil {
+			return err
+		}
+
+		return tx.Model(&deletion).Update("completed_at", time.Now()).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.authService.RevokeAllSessions(deletion.UserID)
+}
+
+// anonymiseUser scrubs the personal fields of a user and soft-deletes the row, so records
+// that reference the user keep a valid foreign key
+func anonymiseUser(tx *gorm.DB, userID uint) error {
+	password, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"email":             fmt.Sprintf("deleted-%d@deleted.invalid", userID),
+		"password":          hashedPassword,
+		"first_name":        "Deleted",
+		"last_name":         "User",
+		"active":            false,
+		"email_verified":    false,
+		"email_verified_at": nil,
+		"mfa_enabled":       false,
+		"mfa_secret":        "",
+	}).Error; err != nil {
+		return err
+	}
+
+	return tx.Delete(&models.User{}, userID).Error
+}
+
+// accountDeletionMethod returns the configured deletion method, anonymising by default
+func accountDeletionMethod() string {
+	if config.AppConfig.AccountDeletionMode == models.AccountDeletionDelete {
+		return models.AccountDeletionDelete
+	}
+	return models.AccountDeletionAnonymise
+}
+
+// StartAccountPurger runs PurgeDueAccounts every ACCOUNT_PURGE_INTERVAL
+func StartAccountPurger() {
+	service := NewPrivacyService()
+
+	go func() {
+		ticker := time.NewTicker(config.AppConfig.AccountPurgeInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			purged, err := service.PurgeDueAccounts()
+			if err != nil {
+				log.Printf("Account purge failed: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d deleted account(s)", purged)
+			}
+		}
+	}()
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -23,6 +24,7 @@ import (
 	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
 	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
 	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
 )
 
 // chunkResult represents the result of processing a chunk
@@ -44,10 +46,15 @@ func NewProductService() *ProductService {
 	}
 }
 
-func (s *ProductService) GetProducts(page, limit int, categoryID *uint) ([]models.Product, int64, error) {
+// GetProducts returns a page of active products, optionally of one category. With
+// includeDescendants the products of its active subcategories, at any depth, are included.
+func (s *ProductService) GetProducts(page, limit int, categoryID *uint, includeDescendants bool) ([]models.Product, int64, error) {
 	cacheKey := fmt.Sprintf("products:page:%d:limit:%d", page, limit)
 	if categoryID != nil {
 		cacheKey += fmt.Sprintf(":category:%d", *categoryID)
+		if includeDescendants {
+			cacheKey += ":descendants"
+		}
 	}
 
 	// Try to get from cache
@@ -61,11 +68,17 @@ func (s *ProductService) GetProducts(page, limit int, categoryID *uint) ([]model
 
 	// Optimize query with specific field selection
 	query := s.db.Model(&models.Product{}).
-		Select("id, index, name, description, short_description, brand, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, category_id, active, created_at, updated_at").
+		Select("id, index, name, description, short_description, brand, brand_id, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, group_key, category_id, active, created_at, updated_at").
 		Where("active = ?", true).
-		Preload("CategoryModel", "active = ?", true)
-
-	if categoryID != nil {
+		Preload("CategoryModel", "active = ?", true).
+		Preload("BrandModel").
+		Preload("Variants", activeVariants).
+		Preload("Images", orderedImages).
+		Preload("Attributes.Attribute")
+
+	if categoryID != nil && includeDescendants {
+		query = query.Where("category_id IN (?)", activeCategoryDescendants(s.db, *categoryID))
+	} else if categoryID != nil {
 		query = query.Where("category_id = ?", *categoryID)
 	}
 
@@ -78,6 +91,9 @@ func (s *ProductService) GetProducts(page, limit int, categoryID *uint) ([]model
 	if err != nil {
 		return nil, 0, err
 	}
+	if err := attachBreadcrumbs(s.db, products); err != nil {
+		return nil, 0, err
+	}
 
 	// Cache for 5 minutes
 	if data, err := json.Marshal(products); err == nil {
@@ -87,12 +103,64 @@ func (s *ProductService) GetProducts(page, limit int, categoryID *uint) ([]model
 	return products, total, nil
 }
 
+// GetProductsByBrand returns a page of the active products of a brand
+func (s *ProductService) GetProductsByBrand(brandID uint, page, limit int) ([]models.Product, int64, error) {
+	cacheKey := fmt.Sprintf("products:brand:%d:page:%d:limit:%d", brandID, page, limit)
+
+	// Try to get from cache
+	ctx := context.Background()
+	cached, err := s.redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var result struct {
+			Products []models.Product `json:"products"`
+			Total    int64            `json:"total"`
+		}
+		if json.Unmarshal([]byte(cached), &result) == nil {
+			return result.Products, result.Total, nil
+		}
+	}
+
+	query := s.db.Model(&models.Product{}).
+		Select("id, index, name, description, short_description, brand, brand_id, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, group_key, category_id, active, created_at, updated_at").
+		Where("active = ? AND brand_id = ?", true, brandID).
+		Preload("CategoryModel", "active = ?", true).
+		Preload("BrandModel").
+		Preload("Variants", activeVariants).
+		Preload("Images", orderedImages).
+		Preload("Attributes.Attribute")
+
+	var total int64
+	query.Count(&total)
+
+	offset := (page - 1) * limit
+	var products []models.Product
+	err = query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&products).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := attachBreadcrumbs(s.db, products); err != nil {
+		return nil, 0, err
+	}
+
+	// Cache for 5 minutes, with the total so cached pages keep their pagination
+	data, err := json.Marshal(map[string]interface{}{"products": products, "total": total})
+	if err == nil {
+		s.redis.Set(ctx, cacheKey, data, 5*time.Minute)
+	}
+
+	return products, total, nil
+}
+
 func (s *ProductService) GetProductsWithoutCache(page, limit int, categoryID *uint) ([]models.Product, int64, error) {
 	// Fetch directly from database without cache for admin dashboard
 	query := s.db.Model(&models.Product{}).
-		Select("id, index, name, description, short_description, brand, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, category_id, active, created_at, updated_at").
+		Select("id, index, name, description, short_description, brand, brand_id, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, group_key, category_id, active, created_at, updated_at").
 		Where("active = ?", true).
-		Preload("CategoryModel") // Always preload CategoryModel
+		Preload("CategoryModel"). // Always preload CategoryModel
+		Preload("BrandModel").
+		Preload("Variants", orderedVariants).
+		Preload("Images", orderedImages).
+		Preload("Attributes.Attribute")
 
 	if categoryID != nil {
 		query = query.Where("category_id = ?", *categoryID)
@@ -107,6 +175,9 @@ func (s *ProductService) GetProductsWithoutCache(page, limit int, categoryID *ui
 	if err != nil {
 		return nil, 0, err
 	}
+	if err := attachBreadcrumbs(s.db, products); err != nil {
+		return nil, 0, err
+	}
 
 	return products, total, nil
 }
@@ -125,12 +196,19 @@ func (s *ProductService) GetProductByID(id uint) (*models.Product, error) {
 
 	// Optimize query with specific field selection
 	var product models.Product
-	err = s.db.Select("id, index, name, description, short_description, brand, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, category_id, active, created_at, updated_at").
+	err = s.db.Select("id, index, name, description, short_description, brand, brand_id, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, group_key, category_id, active, created_at, updated_at").
 		Preload("CategoryModel", "active = ?", true).
+		Preload("BrandModel").
+		Preload("Variants", activeVariants).
+		Preload("Images", orderedImages).
+		Preload("Attributes.Attribute").
 		First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := s.attachProductBreadcrumbs(&product); err != nil {
+		return nil, err
+	}
 
 	// Cache for 10 minutes
 	if data, err := json.Marshal(product); err == nil {
@@ -143,18 +221,25 @@ func (s *ProductService) GetProductByID(id uint) (*models.Product, error) {
 func (s *ProductService) GetProductByIDWithoutCache(id uint) (*models.Product, error) {
 	// Fetch directly from database without cache for admin dashboard
 	var product models.Product
-	err := s.db.Select("id, index, name, description, short_description, brand, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, category_id, active, created_at, updated_at").
+	err := s.db.Select("id, index, name, description, short_description, brand, brand_id, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, group_key, category_id, active, created_at, updated_at").
 		Preload("CategoryModel"). // Always preload CategoryModel
+		Preload("BrandModel").
+		Preload("Variants", orderedVariants).
+		Preload("Images", orderedImages).
+		Preload("Attributes.Attribute").
 		First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := s.attachProductBreadcrumbs(&product); err != nil {
+		return nil, err
+	}
 
 	return &product, nil
 }
 
-func (s *ProductService) SearchProducts(query, category, minPrice, maxPrice, sortBy, sortOrder string, page, limit int) ([]models.Product, int64, error) {
-	cacheKey := fmt.Sprintf("search:%s:%s:%s:%s:%s:%s:%d:%d", query, category, minPrice, maxPrice, sortBy, sortOrder, page, limit)
+func (s *ProductService) SearchProducts(query, category, minPrice, maxPrice, sortBy, sortOrder string, page, limit int, attributes []AttributeFilter) ([]models.Product, int64, error) {
+	cacheKey := fmt.Sprintf("search:%s:%s:%s:%s:%s:%s:%d:%d:%s", query, category, minPrice, maxPrice, sortBy, sortOrder, page, limit, attributeFiltersCacheKey(attributes))
 
 	// Try to get from cache
 	ctx := context.Background()
@@ -167,13 +252,20 @@ func (s *ProductService) SearchProducts(query, category, minPrice, maxPrice, sor
 
 	// Optimize query with specific field selection
 	dbQuery := s.db.Model(&models.Product{}).
-		Select("id, index, name, description, short_description, brand, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, category_id, active, created_at, updated_at").
+		Select("id, index, name, description, short_description, brand, brand_id, category, price, currency, stock, ean, color, size, availability, image, internal_id, slug, sku, group_key, category_id, active, created_at, updated_at").
 		Where("active = ?", true).
-		Preload("CategoryModel", "active = ?", true)
+		Preload("CategoryModel", "active = ?", true).
+		Preload("BrandModel").
+		Preload("Variants", activeVariants).
+		Preload("Images", orderedImages).
+		Preload("Attributes.Attribute")
 
-	// Full-text search
+	// Full-text search; a variant's exact SKU or EAN finds its product
 	if query != "" {
-		dbQuery = dbQuery.Where("to_tsvector('english', name || ' ' || description) @@ plainto_tsquery('english', ?)", query)
+		dbQuery = dbQuery.Where(
+			"(to_tsvector('english', products.name || ' ' || products.description) @@ plainto_tsquery('english', ?) OR "+
+				"EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.active AND (v.sku = ? OR v.ean = ?)))",
+			query, query, query)
 	}
 
 	// Category filter
@@ -182,17 +274,33 @@ func (s *ProductService) SearchProducts(query, category, minPrice, maxPrice, sor
 			Where("categories.slug = ?", category)
 	}
 
-	// Price filters
+	// Price filters match the product's own price or the price of one of its variants,
+	// so each product appears once however many of its variants are in range
+	var priceConditions []string
+	var priceArgs []interface{}
 	if minPrice != "" {
 		if price, err := strconv.ParseFloat(minPrice, 64); err == nil {
-			dbQuery = dbQuery.Where("price >= ?", price)
+			priceConditions = append(priceConditions, "%s >= ?")
+			priceArgs = append(priceArgs, price)
 		}
 	}
 	if maxPrice != "" {
 		if price, err := strconv.ParseFloat(maxPrice, 64); err == nil {
-			dbQuery = dbQuery.Where("price <= ?", price)
+			priceConditions = append(priceConditions, "%s <= ?")
+			priceArgs = append(priceArgs, price)
 		}
 	}
+	if len(priceConditions) > 0 {
+		condition := strings.Join(priceConditions, " AND ")
+		dbQuery = dbQuery.Where(
+			"(("+strings.ReplaceAll(condition, "%s", "products.price")+") OR "+
+				"EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.active AND "+
+				strings.ReplaceAll(condition, "%s", "COALESCE(v.price, products.price)")+"))",
+			append(priceArgs, priceArgs...)...)
+	}
+
+	// Attribute filters
+	dbQuery = applyAttributeFilters(dbQuery, attributes)
 
 	// Sorting
 	if sortBy != "" {
@@ -214,6 +322,9 @@ func (s *ProductService) SearchProducts(query, category, minPrice, maxPrice, sor
 	if err != nil {
 		return nil, 0, err
 	}
+	if err := attachBreadcrumbs(s.db, products); err != nil {
+		return nil, 0, err
+	}
 
 	// Cache for 2 minutes
 	if data, err := json.Marshal(products); err == nil {
@@ -223,6 +334,16 @@ func (s *ProductService) SearchProducts(query, category, minPrice, maxPrice, sor
 	return products, total, nil
 }
 
+// attachProductBreadcrumbs sets the category breadcrumbs of a single product
+func (s *ProductService) attachProductBreadcrumbs(product *models.Product) error {
+	products := []models.Product{*product}
+	if err := attachBreadcrumbs(s.db, products); err != nil {
+		return err
+	}
+	product.Breadcrumbs = products[0].Breadcrumbs
+	return nil
+}
+
 func (s *ProductService) GetCategories() ([]models.Category, error) {
 	cacheKey := "categories"
 
@@ -254,7 +375,7 @@ func (s *ProductService) GetCategories() ([]models.Category, error) {
 
 	// Optimize query with specific field selection
 	var categories []models.Category
-	err = s.db.Select("id, name, description, slug, active, created_at, updated_at").
+	err = s.db.Select(categoryProductCountSelect).
 		Where("active = ?", true).
 		Find(&categories).Error
 	if err != nil {
@@ -282,6 +403,10 @@ func (s *ProductService) GetCategoryByID(id uint) (*models.Category, error) {
 }
 
 func (s *ProductService) CreateProduct(request dto.CreateProductRequest) (*models.Product, error) {
+	if request.Image != "" && !ValidImageFile(request.Image) {
+		return nil, ErrInvalidImageFile
+	}
+
 	// Generate unique values
 	uniqueSlug := s.generateUniqueSlug(request.Name)
 	uniqueSKU := s.generateUniqueSKU(request.Name)
@@ -304,11 +429,37 @@ func (s *ProductService) CreateProduct(request dto.CreateProductRequest) (*model
 		InternalID:       uniqueInternalID,
 		Slug:             uniqueSlug,
 		SKU:              uniqueSKU,
+		GroupKey:         request.GroupKey,
 		CategoryID:       request.CategoryID,
 		Active:           request.Active,
 	}
 
-	err := s.db.Create(&product).Error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := setProductBrand(tx, &product); err != nil {
+			return err
+		}
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+
+		for i, variantRequest := range request.Variants {
+			variant, err := newProductVariant(tx, product, variantRequest, i)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&variant).Error; err != nil {
+				return err
+			}
+			product.Variants = append(product.Variants, variant)
+		}
+
+		attributes, err := createProductAttributes(tx, product, request.Attributes)
+		if err != nil {
+			return err
+		}
+		product.Attributes = attributes
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -363,8 +514,8 @@ func (s *ProductService) UpdateProduct(id uint, request dto.UpdateProductRequest
 	if request.Availability != nil {
 		product.Availability = *request.Availability
 	}
-	if request.Image != nil {
-		product.Image = *request.Image
+	if request.Image != nil && *request.Image != "" && !ValidImageFile(*request.Image) {
+		return nil, ErrInvalidImageFile
 	}
 	if request.InternalID != nil {
 		product.InternalID = *request.InternalID
@@ -375,20 +526,49 @@ func (s *ProductService) UpdateProduct(id uint, request dto.UpdateProductRequest
 	if request.SKU != nil {
 		product.SKU = *request.SKU
 	}
+	categoryChanged := request.CategoryID != nil && *request.CategoryID != product.CategoryID
 	if request.CategoryID != nil {
 		product.CategoryID = *request.CategoryID
 	}
+	if request.GroupKey != nil {
+		product.GroupKey = *request.GroupKey
+	}
 	if request.Active != nil {
 		product.Active = *request.Active
 	}
 
-	err = s.db.Save(&product).Error
+	// The brand name is normalised to its brand, and the image is the product's primary gallery image
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if request.Brand != nil {
+			if err := setProductBrand(tx, &product); err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+		if request.Image != nil {
+			if err := setPrimaryImageFile(tx, product, *request.Image); err != nil {
+				return err
+			}
+		}
+		if request.Attributes != nil || categoryChanged {
+			return updateProductAttributes(tx, product, request.Attributes, categoryChanged)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Preload("BrandModel").Preload("Variants", orderedVariants).Preload("Images", orderedImages).Preload("Attributes.Attribute").
+		First(&product, product.ID).Error
 	if err != nil {
 		return nil, err
 	}
 
 	// Clear cache
-	s.clearProductCache()
+	s.invalidateProduct(product.ID)
 
 	return &product, nil
 }
@@ -400,13 +580,19 @@ func (s *ProductService) DeleteProduct(id uint) error {
 		return err
 	}
 
-	err = s.db.Delete(&product).Error
+	// Variants are removed so their SKUs can be used again
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&product).Error
+	})
 	if err != nil {
 		return err
 	}
 
 	// Clear cache
-	s.clearProductCache()
+	s.invalidateProduct(product.ID)
 
 	return nil
 }
@@ -467,8 +653,30 @@ func (s *ProductService) BulkUploadProducts(file *multipart.FileHeader) (*dto.Bu
 	}
 
 	categoryTime := time.Since(categoryStart)
+
+	// Attribute definitions of the uploaded categories, to validate each row's attributes
+	categoryIDs := make([]uint, 0, len(categoryMap))
+	for _, id := range categoryMap {
+		categoryIDs = append(categoryIDs, id)
+	}
+	attributeDefinitions, err := attributeDefinitionsByCategory(s.db, categoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attribute definitions: %v", err)
+	}
 	fmt.Printf("   Categories processed: %d in %v\n", len(categoryMap), categoryTime)
 
+	// Brand names are normalised to their brands, creating the ones that are new
+	brandMap, err := s.processBrands(productsData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process brands: %v", err)
+	}
+
+	// Variant SKUs must be unique, so rows repeating a taken SKU fail on their own
+	variantSKUs, err := s.loadVariantSKUs(productsData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load variant SKUs: %v", err)
+	}
+
 	// ULTRA-FAST: Ultra-high-performance processing with optimized settings for 50 workers
 	// Optimal chunk size calculation for 50 workers:
 	// - Database connections: 500 max, 150 idle
@@ -476,7 +684,8 @@ func (s *ProductService) BulkUploadProducts(file *multipart.FileHeader) (*dto.Bu
 	// - Safe to use 50 workers with 500 max connections
 	// - Chunk size should be large enough for efficient COPY but not too large for memory
 	chunkSize := 100 // Optimized for 50 workers - smaller chunks for better distribution
-	totalChunks := (len(productsData) + chunkSize - 1) / chunkSize
+	chunks := chunkProductRows(productsData, chunkSize)
+	totalChunks := len(chunks)
 	maxWorkers := 50 // Ultra-high concurrency for maximum throughput
 
 	fmt.Printf("   Processing in %d chunks of %d products each with %d concurrent workers\n", totalChunks, chunkSize, maxWorkers)
@@ -504,7 +713,7 @@ func (s *ProductService) BulkUploadProducts(file *multipart.FileHeader) (*dto.Bu
 					return
 				default:
 					// Process chunk with lightning-fast COPY protocol
-					chunkResult := s.processChunkLightningFast(ctx, chunk, categoryMap, workerID)
+					chunkResult := s.processChunkLightningFast(ctx, chunk, categoryMap, brandMap, attributeDefinitions, variantSKUs, workerID)
 					resultChan <- chunkResult
 				}
 			}
@@ -514,12 +723,7 @@ func (s *ProductService) BulkUploadProducts(file *multipart.FileHeader) (*dto.Bu
 	// LIGHTNING-FAST: Send chunks to workers with optimized batching
 	go func() {
 		defer close(chunkChan)
-		for i := 0; i < len(productsData); i += chunkSize {
-			end := i + chunkSize
-			if end > len(productsData) {
-				end = len(productsData)
-			}
-			chunk := productsData[i:end]
+		for _, chunk := range chunks {
 			select {
 			case chunkChan <- chunk:
 			case <-ctx.Done():
@@ -612,44 +816,68 @@ func (s *ProductService) parseJSONStream(src io.Reader, fileSize int64) ([]map[s
 	return productsData, nil
 }
 
-// processCategoriesParallel processes categories in parallel for lightning-fast performance
+// processCategoriesParallel resolves the categories of the uploaded rows. A category is a plain
+// name or a path such as "Electronics > Audio > Headphones"; missing nodes along the path are
+// created, one level of the tree per round. The returned map goes from the row's category value
+// to the ID of the category at the end of its path.
 func (s *ProductService) processCategoriesParallel(productsData []map[string]interface{}) (map[string]uint, error) {
 	// Extract unique categories
-	categorySet := make(map[string]bool)
+	categoryPaths := make(map[string][]string)
+	depth := 0
 	for _, productData := range productsData {
-		if category, ok := productData["Category"].(string); ok && category != "" {
-			categorySet[category] = true
+		if category, ok := productData["Category"].(string); ok {
+			if path := categoryPath(category); len(path) > 0 {
+				categoryPaths[category] = path
+				depth = max(depth, len(path))
+			}
 		}
 	}
 
-	// Load existing categories
-	var categories []models.Category
-	err := s.db.Model(&models.Category{}).Select("name, id").Find(&categories).Error
-	if err != nil {
-		return nil, err
-	}
-
+	// Every round creates one more level, so depth+1 rounds resolve all paths
 	categoryMap := make(map[string]uint)
-	for _, cat := range categories {
-		categoryMap[cat.Name] = cat.ID
-	}
+	created := 0
+	for round := 0; round <= depth && len(categoryPaths) > 0; round++ {
+		// Load existing categories
+		var categories []models.Category
+		err := s.db.Model(&models.Category{}).Select("id, parent_id, name").Order("id").Find(&categories).Error
+		if err != nil {
+			return nil, err
+		}
+		index := newCategoryIndex(categories)
+
+		// Resolve every path as far as it exists and collect the first missing node of each
+		var newCategories []models.Category
+		pending := make(map[categoryNodeKey]bool)
+		for raw, path := range categoryPaths {
+			id, missing := index.resolve(path)
+			if missing < 0 {
+				categoryMap[raw] = id
+				delete(categoryPaths, raw)
+				continue
+			}
+
+			key := categoryNodeKey{parentID: id, name: path[missing]}
+			if pending[key] {
+				continue
+			}
+			pending[key] = true
 
-	// Find new categories that need to be created
-	var newCategories []models.Category
-	for categoryName := range categorySet {
-		if _, exists := categoryMap[categoryName]; !exists {
 			newCategory := models.Category{
-				Name:        categoryName,
-				Description: fmt.Sprintf("Category for %s", categoryName),
-				Slug:        s.generateCategorySlug(categoryName),
+				Name:        path[missing],
+				Description: fmt.Sprintf("Category for %s", path[missing]),
+				Slug:        s.generateCategorySlug(path[missing]),
 				Active:      true,
 			}
+			if id != 0 {
+				parentID := id
+				newCategory.ParentID = &parentID
+			}
 			newCategories = append(newCategories, newCategory)
 		}
-	}
+		if len(newCategories) == 0 {
+			break
+		}
 
-	// Create new categories in parallel batches
-	if len(newCategories) > 0 {
 		fmt.Printf("   Creating %d new categories in parallel...\n", len(newCategories))
 
 		// Process categories in batches of 100 for ultra-fast processing
@@ -666,21 +894,122 @@ func (s *ProductService) processCategoriesParallel(productsData []map[string]int
 				return nil, fmt.Errorf("failed to create categories batch: %v", err)
 			}
 		}
+		created += len(newCategories)
+	}
+
+	if created > 0 {
+		clearCategoryCaches(s.redis)
+	}
+
+	return categoryMap, nil
+}
+
+// processBrands resolves the brand names of the uploaded rows. Known spellings are looked up in
+// memory; the others go through resolveBrand, which records new spellings and creates new brands.
+// The returned map goes from the row's brand value to its brand.
+func (s *ProductService) processBrands(productsData []map[string]interface{}) (map[string]models.Brand, error) {
+	var brands []models.Brand
+	if err := s.db.Select("id, name").Find(&brands).Error; err != nil {
+		return nil, err
+	}
+	brandsByID := make(map[uint]models.Brand, len(brands))
+	for _, brand := range brands {
+		brandsByID[brand.ID] = brand
+	}
+
+	var aliases []models.BrandAlias
+	if err := s.db.Select("brand_id, alias").Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	brandByAlias := make(map[string]uint, len(aliases))
+	for _, alias := range aliases {
+		brandByAlias[alias.Alias] = alias.BrandID
+	}
 
-		// Reload category map with new categories
-		var updatedCategories []models.Category
-		err = s.db.Model(&models.Category{}).Select("name, id").Find(&updatedCategories).Error
+	brandMap := make(map[string]models.Brand)
+	created := false
+	for _, productData := range productsData {
+		raw, ok := productData["Brand"].(string)
+		if !ok {
+			continue
+		}
+		if _, done := brandMap[raw]; done {
+			continue
+		}
+
+		if brandID, known := brandByAlias[utils.NormalizeBrandName(raw)]; known {
+			if brand, exists := brandsByID[brandID]; exists {
+				brandMap[raw] = brand
+				continue
+			}
+		}
+
+		brand, err := resolveBrand(s.db, raw)
 		if err != nil {
 			return nil, err
 		}
+		if brand != nil {
+			brandMap[raw] = *brand
+			brandsByID[brand.ID] = *brand
+			brandByAlias[utils.NormalizeBrandName(raw)] = brand.ID
+			created = true
+		}
+	}
+
+	if created {
+		clearBrandCaches(s.redis)
+	}
+
+	return brandMap, nil
+}
+
+// categoryNodeKey identifies a category by its parent (0 for the root) and name
+type categoryNodeKey struct {
+	parentID uint
+	name     string
+}
+
+// categoryIndex looks up categories by their position in the tree
+type categoryIndex struct {
+	nodes  map[categoryNodeKey]uint
+	byName map[string]uint
+}
 
-		categoryMap = make(map[string]uint)
-		for _, cat := range updatedCategories {
-			categoryMap[cat.Name] = cat.ID
+func newCategoryIndex(categories []models.Category) categoryIndex {
+	index := categoryIndex{
+		nodes:  make(map[categoryNodeKey]uint, len(categories)),
+		byName: make(map[string]uint, len(categories)),
+	}
+	for _, category := range categories {
+		var parentID uint
+		if category.ParentID != nil {
+			parentID = *category.ParentID
+		}
+		index.nodes[categoryNodeKey{parentID: parentID, name: category.Name}] = category.ID
+		if _, exists := index.byName[category.Name]; !exists {
+			index.byName[category.Name] = category.ID
 		}
 	}
+	return index
+}
 
-	return categoryMap, nil
+// resolve walks a category path from the root. It returns the ID of the last category and -1 when
+// the whole path exists, or the ID of the deepest existing category (0 for the root) and the
+// position of the first missing name. A plain name that is not a root category matches a
+// category of that name anywhere in the tree, as it did before categories were nested.
+func (index categoryIndex) resolve(path []string) (uint, int) {
+	var parentID uint
+	for i, name := range path {
+		id, exists := index.nodes[categoryNodeKey{parentID: parentID, name: name}]
+		if !exists && len(path) == 1 {
+			id, exists = index.byName[name]
+		}
+		if !exists {
+			return parentID, i
+		}
+		parentID = id
+	}
+	return parentID, -1
 }
 
 // insertCategoriesBatch inserts a batch of categories efficiently
@@ -690,48 +1019,62 @@ func (s *ProductService) insertCategoriesBatch(categories []models.Category) err
 	}
 
 	// Use ON CONFLICT DO NOTHING for safe concurrent insertion
-	query := "INSERT INTO categories (name, description, slug, active, created_at, updated_at) VALUES "
+	query := "INSERT INTO categories (parent_id, name, description, slug, active, created_at, updated_at) VALUES "
 	var values []string
 	var args []interface{}
 
 	timestamp := time.Now()
 	for _, cat := range categories {
-		values = append(values, "(?, ?, ?, ?, ?, ?)")
-		args = append(args, cat.Name, cat.Description, cat.Slug, cat.Active, timestamp, timestamp)
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, cat.ParentID, cat.Name, cat.Description, cat.Slug, cat.Active, timestamp, timestamp)
 	}
 
-	query += strings.Join(values, ",") + " ON CONFLICT (name) DO NOTHING"
+	query += strings.Join(values, ",") + " ON CONFLICT DO NOTHING"
 
 	return s.db.Exec(query, args...).Error
 }
 
 // processChunkLightningFast processes a chunk with lightning-fast COPY protocol
-func (s *ProductService) processChunkLightningFast(ctx context.Context, productsData []map[string]interface{}, categoryMap map[string]uint, workerID int) *chunkResult {
+func (s *ProductService) processChunkLightningFast(ctx context.Context, productsData []map[string]interface{}, categoryMap map[string]uint, brandMap map[string]models.Brand, attributeDefinitions map[uint][]models.AttributeDefinition, variantSKUs *variantSKUSet, workerID int) *chunkResult {
 	result := &chunkResult{
 		uploaded: 0,
 		failed:   0,
 		errors:   []string{},
 	}
 
+	// A failed chunk rolls back as a whole: all of its rows fail and its SKUs are free again
+	skuClaims := &variantSKUClaims{set: variantSKUs}
+	failChunk := func(message string) *chunkResult {
+		skuClaims.release()
+		result.uploaded = 0
+		result.failed = len(productsData)
+		result.errors = append(result.errors, message)
+		return result
+	}
+
 	// Get connection from pool with optimized settings
 	conn, err := database.Pool.Acquire(ctx)
 	if err != nil {
-		result.errors = append(result.errors, fmt.Sprintf("Failed to acquire connection: %v", err))
-		return result
+		return failChunk(fmt.Sprintf("Failed to acquire connection: %v", err))
 	}
 	defer conn.Release()
 
 	// Begin transaction with optimized settings
 	tx, err := conn.Begin(ctx)
 	if err != nil {
-		result.errors = append(result.errors, fmt.Sprintf("Failed to begin transaction: %v", err))
-		return result
+		return failChunk(fmt.Sprintf("Failed to begin transaction: %v", err))
 	}
 	defer tx.Rollback(ctx)
 
 	// LIGHTNING-FAST: Pre-allocate products slice
 	products := make([]models.Product, 0, len(productsData))
 
+	// Rows sharing a group key become variants of one product
+	var groups []*variantGroup
+	groupIndex := make(map[string]*variantGroup)
+	attributeValues := make(map[string][]models.ProductAttributeValue)
+	attributeSlugs := make([]string, 0)
+
 	// Process products with optimized conversion
 	for _, productData := range productsData {
 		product, err := s.convertToProductOptimized(productData)
@@ -741,31 +1084,164 @@ func (s *ProductService) processChunkLightningFast(ctx context.Context, products
 			continue
 		}
 
-		// Set category ID
+		// Set category ID; a category path is stored with the name of its last category
 		if product.Category != "" {
 			if categoryID, exists := categoryMap[product.Category]; exists {
 				product.CategoryID = categoryID
 			}
+			if path := categoryPath(product.Category); len(path) > 0 {
+				product.Category = path[len(path)-1]
+			}
+		}
+
+		// Set brand ID and the brand's name
+		if brand, exists := brandMap[product.Brand]; exists {
+			brandID := brand.ID
+			product.Brand = brand.Name
+			product.BrandID = &brandID
+		}
+
+		// Later rows of a group only add a variant to the product. Attributes belong to the
+		// product, so a row that gives them must agree with the group's first row.
+		if group, exists := groupIndex[product.GroupKey]; exists && product.GroupKey != "" {
+			if input := bulkAttributes(productData); len(input) > 0 {
+				attributes, err := resolveAttributeValues(attributeDefinitions[product.CategoryID], input, true)
+				if err != nil {
+					result.failed++
+					result.errors = append(result.errors, fmt.Sprintf("Invalid attributes for product '%s': %v", product.Name, err))
+					continue
+				}
+				if !sameAttributeValues(attributes, group.attributes) {
+					result.failed++
+					result.errors = append(result.errors, fmt.Sprintf("Attributes of product '%s' differ from the first row of group '%s'", product.Name, product.GroupKey))
+					continue
+				}
+			}
+			variant := newBulkVariant(*product, productData)
+			if !skuClaims.claim(variant.SKU) {
+				result.failed++
+				result.errors = append(result.errors, fmt.Sprintf("Duplicate variant SKU '%s' for product '%s'", variant.SKU, product.Name))
+				continue
+			}
+			group.variants = append(group.variants, variant)
+			continue
+		}
+
+		// Attributes are checked against the definitions of the product's category
+		attributes, err := resolveAttributeValues(attributeDefinitions[product.CategoryID], bulkAttributes(productData), true)
+		if err != nil {
+			result.failed++
+			result.errors = append(result.errors, fmt.Sprintf("Invalid attributes for product '%s': %v", product.Name, err))
+			continue
+		}
+		if product.GroupKey == "" {
+			if len(attributes) > 0 {
+				attributeValues[product.Slug] = attributes
+				attributeSlugs = append(attributeSlugs, product.Slug)
+			}
+			products = append(products, *product)
+			continue
+		}
+
+		variant := newBulkVariant(*product, productData)
+		if !skuClaims.claim(variant.SKU) {
+			result.failed++
+			result.errors = append(result.errors, fmt.Sprintf("Duplicate variant SKU '%s' for product '%s'", variant.SKU, product.Name))
+			continue
 		}
 
-		products = append(products, *product)
+		group := newVariantGroup(*product)
+		group.attributes = attributes
+		group.variants = append(group.variants, variant)
+		groupIndex[product.GroupKey] = group
+		groups = append(groups, group)
 	}
 
 	// Insert products using lightning-fast COPY
 	if len(products) > 0 {
 		err = s.insertProductsLightningFast(ctx, tx, products)
 		if err != nil {
-			result.errors = append(result.errors, fmt.Sprintf("Failed to insert products: %v", err))
-			return result
+			return failChunk(fmt.Sprintf("Failed to insert products: %v", err))
+		}
+	}
+
+	// Insert variant groups under new or existing parent products
+	if len(groups) > 0 {
+		err = s.insertVariantGroups(ctx, tx, groups, skuClaims)
+		if err != nil {
+			return failChunk(fmt.Sprintf("Failed to insert product variants: %v", err))
+		}
+	}
+
+	// Rows of a group whose attributes conflict with its existing product are not stored
+	stored := groups[:0]
+	for _, group := range groups {
+		if group.conflict == "" {
+			stored = append(stored, group)
+			continue
+		}
+		result.failed += len(group.variants)
+		result.errors = append(result.errors, group.conflict)
+		skus := make([]string, 0, len(group.variants))
+		for _, variant := range group.variants {
+			skus = append(skus, variant.SKU)
+		}
+		skuClaims.releaseSKUs(skus)
+	}
+	groups = stored
+
+	// Store the attributes of the new products and of the groups' products
+	productAttributes := make(map[uint][]models.ProductAttributeValue)
+	if len(attributeSlugs) > 0 {
+		productIDs, err := productIDsBySlug(ctx, tx, attributeSlugs)
+		if err != nil {
+			return failChunk(fmt.Sprintf("Failed to insert product attributes: %v", err))
+		}
+		for _, slug := range attributeSlugs {
+			productAttributes[productIDs[slug]] = attributeValues[slug]
+		}
+	}
+	for _, group := range groups {
+		if len(group.attributes) > 0 {
+			productAttributes[group.productID] = group.attributes
+		}
+	}
+	if len(productAttributes) > 0 {
+		err = insertAttributeValues(ctx, tx, productAttributes)
+		if err != nil {
+			return failChunk(fmt.Sprintf("Failed to insert product attributes: %v", err))
+		}
+	}
+
+	// Start the image gallery of every new product that has an image
+	slugs := make([]string, 0, len(products)+len(groups))
+	for _, product := range products {
+		if product.Image != "" {
+			slugs = append(slugs, product.Slug)
+		}
+	}
+	for _, group := range groups {
+		if group.parent.Image != "" {
+			slugs = append(slugs, group.parent.Slug)
+		}
+	}
+	if len(slugs) > 0 {
+		err = s.insertPrimaryImages(ctx, tx, slugs)
+		if err != nil {
+			return failChunk(fmt.Sprintf("Failed to insert product images: %v", err))
 		}
-		result.uploaded += len(products)
 	}
 
 	// Commit transaction
 	err = tx.Commit(ctx)
 	if err != nil {
-		result.errors = append(result.errors, fmt.Sprintf("Failed to commit transaction: %v", err))
-		return result
+		return failChunk(fmt.Sprintf("Failed to commit transaction: %v", err))
+	}
+
+	// Rows only count as uploaded once they are committed
+	result.uploaded = len(products)
+	for _, group := range groups {
+		result.uploaded += len(group.variants)
 	}
 
 	return result
@@ -840,6 +1316,7 @@ func (s *ProductService) convertToProductOptimized(data map[string]interface{})
 	if internalID, ok := data["Internal ID"].(string); ok {
 		product.InternalID = internalID
 	}
+	product.GroupKey = bulkGroupKey(data)
 
 	// ULTRA-FAST: Generate unique values using optimized timestamp approach
 	timestamp := time.Now().UnixNano()
@@ -885,6 +1362,7 @@ func (s *ProductService) insertProductsLightningFast(ctx context.Context, tx pgx
 			product.Description,
 			product.ShortDescription,
 			product.Brand,
+			product.BrandID,
 			product.Category,
 			product.Price,
 			product.Currency,
@@ -909,7 +1387,7 @@ func (s *ProductService) insertProductsLightningFast(ctx context.Context, tx pgx
 		ctx,
 		pgx.Identifier{"products"},
 		[]string{
-			"index", "name", "description", "short_description", "brand", "category",
+			"index", "name", "description", "short_description", "brand", "brand_id", "category",
 			"price", "currency", "stock", "ean", "color", "size", "availability",
 			"image", "internal_id", "slug", "sku", "category_id", "active", "created_at", "updated_at",
 		},
@@ -922,6 +1400,356 @@ func (s *ProductService) insertProductsLightningFast(ctx context.Context, tx pgx
 	return nil
 }
 
+// variantGroup collects the rows of one bulk upload group: the product built from its first row
+// and a variant for every row
+type variantGroup struct {
+	parent     models.Product
+	variants   []models.ProductVariant
+	attributes []models.ProductAttributeValue
+
+	// Set by insertVariantGroups: the ID of the new or existing product, and why the group's
+	// rows were not stored
+	productID uint
+	conflict  string
+}
+
+// newVariantGroup starts a group from its first row. Colour, size, EAN and stock belong to the
+// variants, so the parent product does not keep them.
+func newVariantGroup(first models.Product) *variantGroup {
+	parent := first
+	parent.EAN = ""
+	parent.Color = ""
+	parent.Size = ""
+	parent.Stock = 0
+	return &variantGroup{parent: parent}
+}
+
+// variantSKUSet holds the variant SKUs already used by the database or by rows of the current
+// upload. Workers share it to keep SKUs unique across chunks.
+type variantSKUSet struct {
+	mu    sync.Mutex
+	taken map[string]bool
+}
+
+// claim reserves a SKU and reports whether it was still free. An empty SKU is generated later
+// and is always accepted.
+func (set *variantSKUSet) claim(sku string) bool {
+	if sku == "" {
+		return true
+	}
+
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if set.taken[sku] {
+		return false
+	}
+	set.taken[sku] = true
+	return true
+}
+
+// release frees SKUs whose rows were not stored
+func (set *variantSKUSet) release(skus []string) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	for _, sku := range skus {
+		delete(set.taken, sku)
+	}
+}
+
+// variantSKUClaims records the SKUs one chunk claims, so they can be released when the chunk's
+// transaction fails
+type variantSKUClaims struct {
+	set  *variantSKUSet
+	skus []string
+}
+
+func (claims *variantSKUClaims) claim(sku string) bool {
+	if !claims.set.claim(sku) {
+		return false
+	}
+	if sku != "" {
+		claims.skus = append(claims.skus, sku)
+	}
+	return true
+}
+
+// releaseSKUs frees some of the claimed SKUs, for rows that are dropped from the chunk
+func (claims *variantSKUClaims) releaseSKUs(skus []string) {
+	drop := make(map[string]bool, len(skus))
+	for _, sku := range skus {
+		drop[sku] = true
+	}
+	kept := claims.skus[:0]
+	for _, sku := range claims.skus {
+		if !drop[sku] {
+			kept = append(kept, sku)
+		}
+	}
+	claims.skus = kept
+	claims.set.release(skus)
+}
+
+func (claims *variantSKUClaims) release() {
+	claims.set.release(claims.skus)
+	claims.skus = nil
+}
+
+// loadVariantSKUs returns the SKUs given in the uploaded variant rows that existing variants
+// already use
+func (s *ProductService) loadVariantSKUs(productsData []map[string]interface{}) (*variantSKUSet, error) {
+	var skus []string
+	for _, productData := range productsData {
+		if bulkGroupKey(productData) == "" {
+			continue
+		}
+		if sku, ok := productData["SKU"].(string); ok && strings.TrimSpace(sku) != "" {
+			skus = append(skus, strings.TrimSpace(sku))
+		}
+	}
+
+	set := &variantSKUSet{taken: make(map[string]bool)}
+	for i := 0; i < len(skus); i += 1000 {
+		var existing []string
+		batch := skus[i:min(i+1000, len(skus))]
+		if err := s.db.Model(&models.ProductVariant{}).Where("sku IN ?", batch).Pluck("sku", &existing).Error; err != nil {
+			return nil, err
+		}
+		for _, sku := range existing {
+			set.taken[sku] = true
+		}
+	}
+	return set, nil
+}
+
+// variantSKUsWithPrefix returns the existing variant SKUs that start with prefix
+func variantSKUsWithPrefix(ctx context.Context, tx pgx.Tx, prefix string) (map[string]bool, error) {
+	rows, err := tx.Query(ctx, "SELECT sku FROM product_variants WHERE starts_with(sku, $1)", prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skus := make(map[string]bool)
+	for rows.Next() {
+		var sku string
+		if err := rows.Scan(&sku); err != nil {
+			return nil, err
+		}
+		skus[sku] = true
+	}
+	return skus, rows.Err()
+}
+
+// newBulkVariant builds the variant for an uploaded row. Its price is compared with the parent's
+// price when the group is inserted and only kept when it differs.
+func newBulkVariant(product models.Product, data map[string]interface{}) models.ProductVariant {
+	price := product.Price
+	variant := models.ProductVariant{
+		EAN:    product.EAN,
+		Color:  product.Color,
+		Size:   product.Size,
+		Price:  &price,
+		Stock:  product.Stock,
+		Image:  product.Image,
+		Active: true,
+	}
+	if sku, ok := data["SKU"].(string); ok {
+		variant.SKU = strings.TrimSpace(sku)
+	}
+	return variant
+}
+
+// bulkAttributes returns the "Attributes" object of an uploaded row, keyed by attribute key or name
+func bulkAttributes(data map[string]interface{}) map[string]interface{} {
+	attributes, _ := data["Attributes"].(map[string]interface{})
+	return attributes
+}
+
+// bulkGroupKey returns the "Group Key" of an uploaded row, or "" for a standalone product
+func bulkGroupKey(data map[string]interface{}) string {
+	if key, ok := data["Group Key"].(string); ok {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+// chunkProductRows splits uploaded rows into chunks of about chunkSize rows. All rows of a group
+// go into the same chunk, so only one worker creates the group's parent product.
+func chunkProductRows(productsData []map[string]interface{}, chunkSize int) [][]map[string]interface{} {
+	var chunks [][]map[string]interface{}
+	var groups [][]map[string]interface{}
+	groupIndex := make(map[string]int)
+
+	current := make([]map[string]interface{}, 0, chunkSize)
+	for _, productData := range productsData {
+		key := bulkGroupKey(productData)
+		if key == "" {
+			current = append(current, productData)
+			if len(current) == chunkSize {
+				chunks = append(chunks, current)
+				current = make([]map[string]interface{}, 0, chunkSize)
+			}
+			continue
+		}
+
+		if i, exists := groupIndex[key]; exists {
+			groups[i] = append(groups[i], productData)
+			continue
+		}
+		groupIndex[key] = len(groups)
+		groups = append(groups, []map[string]interface{}{productData})
+	}
+
+	// Groups fill the remaining chunks; a group larger than chunkSize gets a chunk of its own
+	for _, group := range groups {
+		if len(current) > 0 && len(current)+len(group) > chunkSize {
+			chunks = append(chunks, current)
+			current = nil
+		}
+		current = append(current, group...)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+// insertVariantGroups adds the variants of each group to the product that already has the group
+// key, or to a new product built from the group's first row, and copies them in one batch
+func (s *ProductService) insertVariantGroups(ctx context.Context, tx pgx.Tx, groups []*variantGroup, skuClaims *variantSKUClaims) error {
+	var rows [][]interface{}
+	timestamp := time.Now()
+
+	for _, group := range groups {
+		parent := group.parent
+
+		var productID uint
+		var parentPrice float64
+		var parentSKU string
+		err := tx.QueryRow(ctx,
+			"SELECT id, price, sku FROM products WHERE group_key = $1 AND deleted_at IS NULL ORDER BY id LIMIT 1",
+			parent.GroupKey,
+		).Scan(&productID, &parentPrice, &parentSKU)
+		if err == nil {
+			// The rows join an existing product; its stored attributes must agree with theirs
+			stored, err := productAttributeValuesTx(ctx, tx, productID)
+			if err != nil {
+				return fmt.Errorf("failed to load attributes for group '%s': %v", parent.GroupKey, err)
+			}
+			if len(stored) > 0 && len(group.attributes) > 0 {
+				if !sameAttributeValues(stored, group.attributes) {
+					group.conflict = fmt.Sprintf("Attributes of group '%s' differ from its existing product", parent.GroupKey)
+					continue
+				}
+				group.attributes = nil
+			}
+		} else if errors.Is(err, pgx.ErrNoRows) {
+			err = tx.QueryRow(ctx, `
+				INSERT INTO products (index, name, description, short_description, brand, brand_id, category, price,
+					currency, stock, ean, color, size, availability, image, internal_id, slug, sku, group_key,
+					category_id, active, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $22)
+				RETURNING id`,
+				parent.Index, parent.Name, parent.Description, parent.ShortDescription, parent.Brand, parent.BrandID,
+				parent.Category, parent.Price, parent.Currency, parent.Stock, parent.EAN, parent.Color, parent.Size,
+				parent.Availability, parent.Image, parent.InternalID, parent.Slug, parent.SKU, parent.GroupKey,
+				parent.CategoryID, parent.Active, timestamp,
+			).Scan(&productID)
+			parentPrice, parentSKU = parent.Price, parent.SKU
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find or create product for group '%s': %v", parent.GroupKey, err)
+		}
+		group.productID = productID
+
+		// New variants go after the ones the product already has
+		var existing int
+		err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM product_variants WHERE product_id = $1", productID).Scan(&existing)
+		if err != nil {
+			return fmt.Errorf("failed to count variants for group '%s': %v", parent.GroupKey, err)
+		}
+
+		// Generated SKUs skip the ones the product's earlier variants or other rows already use
+		var generated map[string]bool
+		for _, variant := range group.variants {
+			if variant.SKU == "" {
+				generated, err = variantSKUsWithPrefix(ctx, tx, parentSKU+"-")
+				if err != nil {
+					return fmt.Errorf("failed to load variant SKUs for group '%s': %v", parent.GroupKey, err)
+				}
+				break
+			}
+		}
+
+		next := existing + 1
+		for i, variant := range group.variants {
+			position := existing + i
+			for variant.SKU == "" {
+				sku := fmt.Sprintf("%s-%d", parentSKU, next)
+				next++
+				if !generated[sku] && skuClaims.claim(sku) {
+					variant.SKU = sku
+				}
+			}
+			if variant.Price != nil && *variant.Price == parentPrice {
+				variant.Price = nil
+			}
+
+			rows = append(rows, []interface{}{
+				productID,
+				variant.SKU,
+				variant.EAN,
+				variant.Color,
+				variant.Size,
+				variant.Price,
+				variant.Stock,
+				variant.Image,
+				position,
+				variant.Active,
+				timestamp,
+				timestamp,
+			})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	_, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"product_variants"},
+		[]string{
+			"product_id", "sku", "ean", "color", "size", "price", "stock", "image",
+			"position", "active", "created_at", "updated_at",
+		},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy product variants: %v", err)
+	}
+
+	return nil
+}
+
+// insertPrimaryImages adds the image of each new product to its gallery as the primary image.
+// COPY does not return IDs, so the products are found by their generated slugs.
+func (s *ProductService) insertPrimaryImages(ctx context.Context, tx pgx.Tx, slugs []string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO product_images (product_id, file, alt_text, position, is_primary, width, height, created_at, updated_at)
+		SELECT p.id, p.image, p.name, 0, true, 0, 0, $2, $2
+		FROM products p
+		WHERE p.slug = ANY($1) AND p.image <> ''
+			AND NOT EXISTS (SELECT 1 FROM product_images i WHERE i.product_id = p.id)`,
+		slugs, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert product images: %v", err)
+	}
+
+	return nil
+}
+
 // generateBulkSlugOptimized generates a unique slug for bulk operations with optimized performance
 func (s *ProductService) generateBulkSlugOptimized(name string, timestamp int64) string {
 	baseSlug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
@@ -953,7 +1781,22 @@ func (s *ProductService) generateCategorySlug(name string) string {
 	return fmt.Sprintf("%s-%d", baseSlug, timestamp)
 }
 
+// clearCatalogCaches clears every cached product, product list and search result
+func (s *ProductService) clearCatalogCaches() {
+	ctx := context.Background()
+	for _, pattern := range []string{"products:*", "product:*", "search:*"} {
+		iter := s.redis.Scan(ctx, 0, pattern, 1000).Iterator()
+		for iter.Next(ctx) {
+			s.redis.Del(ctx, iter.Val())
+		}
+	}
+}
+
 func (s *ProductService) clearProductCache() {
+	// Category and brand listings include product counts
+	clearCategoryCaches(s.redis)
+	clearBrandCaches(s.redis)
+
 	ctx := context.Background()
 	keys, err := s.redis.Keys(ctx, "products:*").Result()
 	if err == nil {
@@ -963,14 +1806,45 @@ func (s *ProductService) clearProductCache() {
 	}
 }
 
+// invalidateProduct clears the cached product lists and the cached copy of one product
+func (s *ProductService) invalidateProduct(id uint) {
+	s.redis.Del(context.Background(), fmt.Sprintf("product:%d", id))
+	s.clearProductCache()
+}
+
+// activeVariants preloads the variants shown to customers, in display order
+func activeVariants(db *gorm.DB) *gorm.DB {
+	return orderedVariants(db).Where("active = ?", true)
+}
+
+// orderedImages preloads a product's gallery in display order
+func orderedImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+// orderedVariants preloads all variants in display order
+func orderedVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+// cacheKeyPatterns lists the Redis key patterns that only hold cached data.
+// Sessions, refresh tokens and other auth state live in the same Redis and must survive a cache clear.
+var cacheKeyPatterns = []string{"products:*", "product:*", "search:*", "categories", "categories:*", "brands", "role_permissions:*"}
+
 // ClearAllCaches clears all Redis caches
 func (s *ProductService) ClearAllCaches() error {
 	ctx := context.Background()
 
-	// Clear all keys (be careful with this in production)
-	err := s.redis.FlushAll(ctx).Err()
-	if err != nil {
-		return err
+	for _, pattern := range cacheKeyPatterns {
+		iter := s.redis.Scan(ctx, 0, pattern, 1000).Iterator()
+		for iter.Next(ctx) {
+			if err := s.redis.Del(ctx, iter.Val()).Err(); err != nil {
+				return err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -1039,8 +1913,13 @@ func (s *ProductService) generateUniqueInternalID(name string) string {
 }
 
 func (s *ProductService) DeleteAllProducts() error {
-	// Delete all products
-	err := s.db.Where("1 = 1").Delete(&models.Product{}).Error
+	// Delete all products and their variants
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ProductVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("1 = 1").Delete(&models.Product{}).Error
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+)
+
+var (
+	ErrInvalidImageFile  = errors.New("image must be a file name in the product image folder or an http(s) URL")
+	ErrImageOrderInvalid = errors.New("image_ids must list every image of the product exactly once")
+)
+
+type ProductImageService struct {
+	db             *gorm.DB
+	productService *ProductService
+}
+
+func NewProductImageService() *ProductImageService {
+	return &ProductImageService{
+		db:             database.DB,
+		productService: NewProductService(),
+	}
+}
+
+// AttachImage adds an image to the end of a product's gallery
+func (s *ProductImageService) AttachImage(productID uint, request dto.AttachProductImageRequest) (*models.ProductImage, error) {
+	file := strings.TrimSpace(request.File)
+	if !ValidImageFile(file) {
+		return nil, ErrInvalidImageFile
+	}
+
+	image := models.ProductImage{
+		ProductID: productID,
+		File:      file,
+		AltText:   request.AltText,
+		IsPrimary: request.Primary,
+		Width:     request.Width,
+		Height:    request.Height,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.Product{}, productID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+			return err
+		}
+		image.Position = int(count)
+
+		if err := tx.Create(&image).Error; err != nil {
+			return err
+		}
+		if image.IsPrimary {
+			if err := makePrimaryImage(tx, productID, image.ID); err != nil {
+				return err
+			}
+		}
+		return syncPrimaryImage(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.productService.invalidateProduct(productID)
+
+	return s.getImage(productID, image.ID)
+}
+
+// UpdateImage changes the alt text, dimensions or primary flag of a product image
+func (s *ProductImageService) UpdateImage(productID, imageID uint, request dto.UpdateProductImageRequest) (*models.ProductImage, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var image models.ProductImage
+		if err := tx.Where("id = ? AND product_id = ?", imageID, productID).First(&image).Error; err != nil {
+			return err
+		}
+
+		if request.AltText != nil {
+			image.AltText = *request.AltText
+		}
+		if request.Width != nil {
+			image.Width = *request.Width
+		}
+		if request.Height != nil {
+			image.Height = *request.Height
+		}
+		if err := tx.Save(&image).Error; err != nil {
+			return err
+		}
+
+		if request.Primary {
+			if err := makePrimaryImage(tx, productID, image.ID); err != nil {
+				return err
+			}
+		}
+		return syncPrimaryImage(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.productService.invalidateProduct(productID)
+
+	return s.getImage(productID, imageID)
+}
+
+// ReorderImages sets the display order of a product's gallery. The request must list all of
+// the product's images, so no two images end up at the same position.
+func (s *ProductImageService) ReorderImages(productID uint, imageIDs []uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.Product{}, productID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", productID).Find(&images).Error; err != nil {
+			return err
+		}
+
+		positions := make(map[uint]int, len(imageIDs))
+		for i, id := range imageIDs {
+			positions[id] = i
+		}
+		if len(imageIDs) != len(images) || len(positions) != len(images) {
+			return ErrImageOrderInvalid
+		}
+
+		for i := range images {
+			position, listed := positions[images[i].ID]
+			if !listed {
+				return ErrImageOrderInvalid
+			}
+			images[i].Position = position
+			if err := tx.Model(&images[i]).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.productService.invalidateProduct(productID)
+
+	return s.GetImages(productID)
+}
+
+// DetachImage removes an image from a product's gallery. When it was the primary image, the
+// first remaining image becomes primary.
+func (s *ProductImageService) DetachImage(productID, imageID uint) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND product_id = ?", imageID, productID).Delete(&models.ProductImage{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Close the gap in the positions
+		if err := tx.Exec(`
+			UPDATE product_images SET position = ordered.position
+			FROM (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) - 1 AS position
+				FROM product_images WHERE product_id = ?
+			) AS ordered
+			WHERE product_images.id = ordered.id
+		`, productID).Error; err != nil {
+			return err
+		}
+
+		return syncPrimaryImage(tx, productID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.productService.invalidateProduct(productID)
+
+	return nil
+}
+
+// GetImages returns a product's gallery in display order
+func (s *ProductImageService) GetImages(productID uint) ([]models.ProductImage, error) {
+	var images []models.ProductImage
+	err := s.db.Scopes(orderedImages).Where("product_id = ?", productID).Find(&images).Error
+	return images, err
+}
+
+func (s *ProductImageService) getImage(productID, imageID uint) (*models.ProductImage, error) {
+	var image models.ProductImage
+	if err := s.db.Where("id = ? AND product_id = ?", imageID, productID).First(&image).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// ValidImageFile reports whether file is a plain file name or an absolute http(s) URL.
+// File names must not contain a path, so they always stay inside the product image folder.
+func ValidImageFile(file string) bool {
+	if strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://") {
+		parsed, err := url.Parse(file)
+		return err == nil && parsed.Host != ""
+	}
+	return file != "" && file != "." && file != ".." && !strings.ContainsAny(file, "/\\")
+}
+
+// makePrimaryImage marks one image as the primary image of its product and clears the flag on the others
+func makePrimaryImage(tx *gorm.DB, productID, imageID uint) error {
+	return tx.Model(&models.ProductImage{}).
+		Where("product_id = ?", productID).
+		Update("is_primary", gorm.Expr("id = ?", imageID)).Error
+}
+
+// syncPrimaryImage makes sure a product with images has exactly one primary image, the first one
+// when none is marked, and copies its file to products.image
+func syncPrimaryImage(tx *gorm.DB, productID uint) error {
+	var images []models.ProductImage
+	if err := tx.Where("product_id = ?", productID).Order("is_primary DESC, position, id").Find(&images).Error; err != nil {
+		return err
+	}
+
+	file := ""
+	if len(images) > 0 {
+		primary := images[0]
+		file = primary.File
+		if !primary.IsPrimary || (len(images) > 1 && images[1].IsPrimary) {
+			if err := makePrimaryImage(tx, productID, primary.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Update("image", file).Error
+}
+
+// setPrimaryImageFile replaces the file of a product's primary image, for clients that still
+// set Product.Image directly. An empty file removes the primary image.
+func setPrimaryImageFile(tx *gorm.DB, product models.Product, file string) error {
+	var primary models.ProductImage
+	err := tx.Where("product_id = ? AND is_primary = ?", product.ID, true).First(&primary).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if file == "" {
+			return nil
+		}
+		var count int64
+		if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", product.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		image := models.ProductImage{
+			ProductID: product.ID,
+			File:      file,
+			AltText:   product.Name,
+			Position:  int(count),
+			IsPrimary: true,
+		}
+		if err := tx.Create(&image).Error; err != nil {
+			return err
+		}
+		if err := makePrimaryImage(tx, product.ID, image.ID); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case file == "":
+		if err := tx.Delete(&primary).Error; err != nil {
+			return err
+		}
+	default:
+		if err := tx.Model(&primary).Update("file", file).Error; err != nil {
+			return err
+		}
+	}
+
+	return syncPrimaryImage(tx, product.ID)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/dto"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+)
+
+var ErrVariantSKUTaken = errors.New("a variant with this SKU already exists")
+
+type ProductVariantService struct {
+	db             *gorm.DB
+	productService *ProductService
+}
+
+func NewProductVariantService() *ProductVariantService {
+	return &ProductVariantService{
+		db:             database.DB,
+		productService: NewProductService(),
+	}
+}
+
+// CreateVariant adds a variant to the end of a product's variant list. It returns the product
+// with the new variant, since variants without a price override sell at the product's price.
+func (s *ProductVariantService) CreateVariant(productID uint, request dto.CreateProductVariantRequest) (*models.Product, *models.ProductVariant, error) {
+	var product models.Product
+	var variant models.ProductVariant
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&product, productID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.ProductVariant{}).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		var err error
+		variant, err = newProductVariant(tx, product, request, int(count))
+		if err != nil {
+			return err
+		}
+		return tx.Create(&variant).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.productService.invalidateProduct(productID)
+
+	return &product, &variant, nil
+}
+
+// UpdateVariant changes the fields of a product's variant that are set in the request
+func (s *ProductVariantService) UpdateVariant(productID, variantID uint, request dto.UpdateProductVariantRequest) (*models.Product, *models.ProductVariant, error) {
+	var product models.Product
+	var variant models.ProductVariant
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&product, productID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND product_id = ?", variantID, productID).First(&variant).Error; err != nil {
+			return err
+		}
+
+		if request.SKU != nil && *request.SKU != variant.SKU {
+			sku := strings.TrimSpace(*request.SKU)
+			if err := ensureVariantSKUAvailable(tx, sku); err != nil {
+				return err
+			}
+			variant.SKU = sku
+		}
+		if request.EAN != nil {
+			variant.EAN = *request.EAN
+		}
+		if request.Color != nil {
+			variant.Color = *request.Color
+		}
+		if request.Size != nil {
+			variant.Size = *request.Size
+		}
+		if request.ClearPrice {
+			variant.Price = nil
+		} else if request.Price != nil {
+			variant.Price = request.Price
+		}
+		if request.Stock != nil {
+			variant.Stock = *request.Stock
+		}
+		if request.Image != nil {
+			variant.Image = *request.Image
+		}
+		if request.Position != nil {
+			variant.Position = *request.Position
+		}
+		if request.Active != nil {
+			variant.Active = *request.Active
+		}
+
+		return tx.Save(&variant).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.productService.invalidateProduct(productID)
+
+	return &product, &variant, nil
+}
+
+// DeleteVariant removes a variant from a product
+func (s *ProductVariantService) DeleteVariant(productID, variantID uint) error {
+	result := s.db.Where("id = ? AND product_id = ?", variantID, productID).Delete(&models.ProductVariant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	s.productService.invalidateProduct(productID)
+
+	return nil
+}
+
+// newProductVariant builds a variant of product at the given position. A SKU is generated from
+// the product's SKU, colour and size when the request has none.
+func newProductVariant(tx *gorm.DB, product models.Product, request dto.CreateProductVariantRequest, position int) (models.ProductVariant, error) {
+	variant := models.ProductVariant{
+		ProductID: product.ID,
+		SKU:       strings.TrimSpace(request.SKU),
+		EAN:       request.EAN,
+		Color:     request.Color,
+		Size:      request.Size,
+		Price:     request.Price,
+		Stock:     request.Stock,
+		Image:     request.Image,
+		Position:  position,
+		Active:    true,
+	}
+
+	if variant.SKU == "" {
+		variant.SKU = generateUniqueVariantSKU(tx, product.SKU, request.Color, request.Size)
+		return variant, nil
+	}
+
+	return variant, ensureVariantSKUAvailable(tx, variant.SKU)
+}
+
+func ensureVariantSKUAvailable(tx *gorm.DB, sku string) error {
+	var count int64
+	if err := tx.Model(&models.ProductVariant{}).Where("sku = ?", sku).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVariantSKUTaken
+	}
+	return nil
+}
+
+// generateUniqueVariantSKU builds a SKU like TSHIRT-RED-XL from the product's SKU and the
+// variant's options, adding a counter when it is already taken
+func generateUniqueVariantSKU(tx *gorm.DB, productSKU, color, size string) string {
+	parts := []string{productSKU}
+	for _, option := range []string{color, size} {
+		if option = strings.ToUpper(strings.ReplaceAll(option, " ", "")); option != "" {
+			parts = append(parts, option)
+		}
+	}
+	baseSKU := strings.Join(parts, "-")
+	sku := baseSKU
+	counter := 1
+
+	for {
+		var count int64
+		tx.Model(&models.ProductVariant{}).Where("sku = ?", sku).Count(&count)
+		if count == 0 {
+			break
+		}
+		sku = fmt.Sprintf("%s-%d", baseSKU, counter)
+		counter++
+	}
+
+	return sku
+}
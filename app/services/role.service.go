@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+)
+
+type RoleService struct {
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+func NewRoleService() *RoleService {
+	return &RoleService{
+		db:    database.DB,
+		redis: database.Redis,
+	}
+}
+
+// GetPermissionsForRole returns the permission names granted to a role, cached in Redis
+func (s *RoleService) GetPermissionsForRole(roleName string) ([]string, error) {
+	cacheKey := "role_permissions:" + roleName
+
+	// Try to get from cache
+	ctx := context.Background()
+	cached, err := s.redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var permissions []string
+		if json.Unmarshal([]byte(cached), &permissions) == nil {
+			return permissions, nil
+		}
+	}
+
+	var role models.Role
+	err = s.db.Preload("Permissions").Where("name = ?", roleName).First(&role).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+
+	// Cache for 5 minutes
+	if data, err := json.Marshal(permissions); err == nil {
+		s.redis.Set(ctx, cacheKey, data, 5*time.Minute)
+	}
+
+	return permissions, nil
+}
+
+// HasPermission reports whether the role has been granted the permission
+func (s *RoleService) HasPermission(roleName, permission string) (bool, error) {
+	permissions, err := s.GetPermissionsForRole(roleName)
+	if err != nil {
+		return false, err
+	}
+
+	for _, p := range permissions {
+		if p == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *RoleService) GetRoles() ([]models.Role, error) {
+	var roles []models.Role
+	if err := s.db.Preload("Permissions").Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *RoleService) GetPermissions() ([]models.Permission, error) {
+	var permissions []models.Permission
+	if err := s.db.Order("name ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// RoleExists reports whether a role with the given name is defined
+func (s *RoleService) RoleExists(roleName string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.Role{}).Where("name = ?", roleName).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *RoleService) CreateRole(name, description string, permissionNames []string) (*models.Role, error) {
+	exists, err := s.RoleExists(name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("role already exists")
+	}
+
+	permissions, err := s.findPermissions(permissionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	role := models.Role{
+		Name:        name,
+		Description: description,
+		Permissions: permissions,
+	}
+	if err := s.db.Create(&role).Error; err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// SetRolePermissions replaces the permissions granted to a role
+func (s *RoleService) SetRolePermissions(roleID uint, permissionNames []string) (*models.Role, error) {
+	var role models.Role
+	if err := s.db.First(&role, roleID).Error; err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.findPermissions(permissionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+		return nil, err
+	}
+
+	s.redis.Del(context.Background(), "role_permissions:"+role.Name)
+
+	role.Permissions = permissions
+	return &role, nil
+}
+
+// findPermissions loads permissions by name and fails on unknown names
+func (s *RoleService) findPermissions(names []string) ([]models.Permission, error) {
+	permissions := []models.Permission{}
+	if len(names) == 0 {
+		return permissions, nil
+	}
+
+	if err := s.db.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	if len(permissions) != len(names) {
+		known := make(map[string]bool, len(permissions))
+		for _, permission := range permissions {
+			known[permission.Name] = true
+		}
+		for _, name := range names {
+			if !known[name] {
+				return nil, errors.New("unknown permission: " + name)
+			}
+		}
+	}
+
+	return permissions, nil
+}
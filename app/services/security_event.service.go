@@ -0,0 +1,144 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+)
+
+// securityEventPruneInterval is how often expired security events are deleted
+const securityEventPruneInterval = time.Hour
+
+func init() {
+	RegisterPersonalDataSection(PersonalDataSection{
+		Name: "security_events",
+		Export: func(tx *gorm.DB, userID uint) (interface{}, error) {
+			var events []models.SecurityEvent
+			err := tx.Where("user_id = ?", userID).Order("created_at DESC").Find(&events).Error
+			return events, err
+		},
+		Erase: func(tx *gorm.DB, userID uint) error {
+			return tx.Where("user_id = ?", userID).Delete(&models.SecurityEvent{}).Error
+		},
+	})
+}
+
+// SecurityEventFilter narrows down the admin security event query
+type SecurityEventFilter struct {
+	UserID  *uint
+	Type    string
+	Outcome string
+	IP      string
+	Email   string
+	From    *time.Time
+	To      *time.Time
+	Page    int
+	Limit   int
+}
+
+type SecurityEventService struct {
+	db *gorm.DB
+}
+
+func NewSecurityEventService() *SecurityEventService {
+	return &SecurityEventService{
+		db: database.DB,
+	}
+}
+
+// Record stores a security event. Events about an email address without a user, such as
+// failed logins, are linked to the account with that address when there is one. Failures
+// are logged rather than returned so they never block the action being recorded.
+func (s *SecurityEventService) Record(event models.SecurityEvent) {
+	event.Email = strings.ToLower(strings.TrimSpace(event.Email))
+
+	if event.UserID == nil && event.Email != "" {
+		var user models.User
+		if err := s.db.Select("id").Where("LOWER(email) = ?", event.Email).First(&user).Error; err == nil {
+			event.UserID = &user.ID
+		}
+	}
+
+	if err := s.db.Create(&event).Error; err != nil {
+		log.Printf("Failed to record %s security event: %v", event.Type, err)
+	}
+}
+
+// GetUserEvents returns a page of the events on a user's account, newest first
+func (s *SecurityEventService) GetUserEvents(userID uint, page, limit int) ([]models.SecurityEvent, int64, error) {
+	return s.GetEvents(SecurityEventFilter{UserID: &userID, Page: page, Limit: limit})
+}
+
+// GetEvents returns a page of events matching the filter, newest first
+func (s *SecurityEventService) GetEvents(filter SecurityEventFilter) ([]models.SecurityEvent, int64, error) {
+	query := s.db.Model(&models.SecurityEvent{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.Email != "" {
+		query = query.Where("email = ?", strings.ToLower(strings.TrimSpace(filter.Email)))
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	var events []models.SecurityEvent
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(filter.Limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// Prune deletes events older than the retention period
+func (s *SecurityEventService) Prune() (int64, error) {
+	cutoff := time.Now().Add(-config.AppConfig.SecurityEventRetention)
+	result := s.db.Where("created_at < ?", cutoff).Delete(&models.SecurityEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// StartSecurityEventPruner applies SECURITY_EVENT_RETENTION once an hour
+func StartSecurityEventPruner() {
+	service := NewSecurityEventService()
+
+	go func() {
+		ticker := time.NewTicker(securityEventPruneInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			pruned, err := service.Prune()
+			if err != nil {
+				log.Printf("Security event pruning failed: %v", err)
+				continue
+			}
+			if pruned > 0 {
+				log.Printf("Pruned %d expired security events", pruned)
+			}
+		}
+	}()
+}
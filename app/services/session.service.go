@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+)
+
+// sessionTouchInterval limits how often last-seen times are written for a session
+const sessionTouchInterval = time.Minute
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Device describes the client a session was started from
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// Session is a login on one device. Its ID is the refresh token family, so it survives
+// token rotation and revoking it ends the access and refresh tokens issued within it.
+// ImpersonatorID is set when an admin is acting as the user in this session.
+type Session struct {
+	ID             string
+	UserAgent      string
+	IP             string
+	MFA            bool
+	ImpersonatorID uint
+	CreatedAt      time.Time
+	LastSeenAt     time.Time
+}
+
+type SessionService struct {
+	redis *redis.Client
+}
+
+func NewSessionService() *SessionService {
+	return &SessionService{
+		redis: database.Redis,
+	}
+}
+
+// GetSessions lists the live sessions of a user, most recently used first
+func (s *SessionService) GetSessions(userID uint) ([]Session, error) {
+	ctx := context.Background()
+	userKey := userFamiliesKey(userID)
+
+	families, err := s.redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(families))
+	for _, family := range families {
+		meta, err := s.redis.HGetAll(ctx, sessionMetaKey(family)).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		// Families expire on their own; drop the ones that are gone from the user's set
+		if len(meta) == 0 {
+			exists, err := s.redis.Exists(ctx, "refresh_family:"+family).Result()
+			if err != nil {
+				return nil, err
+			}
+			if exists == 0 {
+				s.redis.SRem(ctx, userKey, family)
+				continue
+			}
+		}
+
+		impersonatorID, _ := strconv.ParseUint(meta["impersonator_id"], 10, 64)
+		sessions = append(sessions, Session{
+			ID:             family,
+			UserAgent:      meta["user_agent"],
+			IP:             meta["ip"],
+			MFA:            meta["mfa"] == "1",
+			ImpersonatorID: uint(impersonatorID),
+			CreatedAt:      unixField(meta["created_at"]),
+			LastSeenAt:     unixField(meta["last_seen_at"]),
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions
+func (s *SessionService) RevokeSession(userID uint, sessionID string) error {
+	ctx := context.Background()
+	userKey := userFamiliesKey(userID)
+
+	member, err := s.redis.SIsMember(ctx, userKey, sessionID).Result()
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrSessionNotFound
+	}
+
+	revokeTokenFamily(ctx, s.redis, sessionID)
+	return s.redis.SRem(ctx, userKey, sessionID).Err()
+}
+
+// Touch records activity on a session, writing at most once per sessionTouchInterval
+func (s *SessionService) Touch(sessionID, ip string) {
+	ctx := context.Background()
+
+	first, err := s.redis.SetNX(ctx, "session_seen:"+sessionID, 1, sessionTouchInterval).Result()
+	if err != nil || !first {
+		return
+	}
+
+	// Only update sessions that still exist, so revoked sessions are not recreated
+	metaKey := sessionMetaKey(sessionID)
+	if exists, err := s.redis.Exists(ctx, metaKey).Result(); err != nil || exists == 0 {
+		return
+	}
+	s.redis.HSet(ctx, metaKey, "last_seen_at", time.Now().Unix(), "ip", ip)
+}
+
+// recordSession stores or refreshes the device metadata of a session within a pipeline
+func recordSession(ctx context.Context, pipe redis.Pipeliner, family string, userID uint, device Device, mfa bool, created bool) {
+	now := time.Now().Unix()
+	metaKey := sessionMetaKey(family)
+
+	if created {
+		pipe.HSet(ctx, metaKey, "user_id", userID, "user_agent", device.UserAgent, "mfa", mfa, "created_at", now)
+	}
+	pipe.HSet(ctx, metaKey, "ip", device.IP, "last_seen_at", now)
+	pipe.Expire(ctx, metaKey, config.AppConfig.RefreshTokenTTL)
+	pipe.SAdd(ctx, "refresh_family:"+family, metaKey)
+}
+
+// revokeTokenFamily deletes every session and refresh token issued within a family
+func revokeTokenFamily(ctx context.Context, rdb *redis.Client, family string) {
+	familyKey := "refresh_family:" + family
+	keys, err := rdb.SMembers(ctx, familyKey).Result()
+	if err != nil {
+		return
+	}
+	rdb.Del(ctx, append(keys, familyKey)...)
+}
+
+func sessionMetaKey(family string) string {
+	return "session_meta:" + family
+}
+
+func unixField(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
@@ -46,9 +46,9 @@ func (s *StatisticsService) CalculateProductStatistics() (*ProductStatistics, er
 	}
 	stats.TotalProducts = totalProducts
 
-	// Calculate unique brands
+	// Calculate unique brands, counting spellings of the same brand once
 	var uniqueBrands int64
-	if err := s.db.Model(&models.Product{}).Where("active = ? AND brand != ''", true).Distinct("brand").Count(&uniqueBrands).Error; err != nil {
+	if err := s.db.Model(&models.Product{}).Where("active = ? AND brand_id IS NOT NULL", true).Distinct("brand_id").Count(&uniqueBrands).Error; err != nil {
 		return nil, fmt.Errorf("failed to count unique brands: %w", err)
 	}
 	stats.UniqueBrands = uniqueBrands
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+// User statuses accepted by UserFilter
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
+	UserStatusDeleted  = "deleted"
+)
+
+var (
+	ErrCannotModifySelf = errors.New("you cannot change your own role, status or account")
+	ErrUserNotDeleted   = errors.New("user is not deleted")
+)
+
+// UserFilter narrows down the admin user list
+type UserFilter struct {
+	Search string
+	Role   string
+	Status string
+	Page   int
+	Limit  int
+}
+
+type UserService struct {
+	db          *gorm.DB
+	authService *AuthService
+	roleService *RoleService
+}
+
+func NewUserService() *UserService {
+	return &UserService{
+		db:          database.DB,
+		authService: NewAuthService(),
+		roleService: NewRoleService(),
+	}
+}
+
+// GetUsers returns a page of users matching the filter. Deleted users are only
+// included when filtering on the deleted status.
+func (s *UserService) GetUsers(filter UserFilter) ([]models.User, int64, error) {
+	query := s.db.Model(&models.User{})
+
+	switch filter.Status {
+	case UserStatusActive:
+		query = query.Where("active = ?", true)
+	case UserStatusInactive:
+		query = query.Where("active = ?", false)
+	case UserStatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + strings.ToLower(search) + "%"
+		// Phone numbers are encrypted, so they only match exactly through the blind index
+		phoneIndex := utils.BlindIndex(utils.NormalizePhone(search))
+		query = query.Where("LOWER(email) LIKE ? OR LOWER(first_name) LIKE ? OR LOWER(last_name) LIKE ? OR id IN (?)",
+			pattern, pattern, pattern,
+			s.db.Model(&models.UserProfile{}).Select("user_id").Where("phone_index <> '' AND phone_index = ?", phoneIndex))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	var users []models.User
+	if err := query.Offset(offset).Limit(filter.Limit).Order("created_at DESC").Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// GetUserWithProfile returns a user, including soft-deleted ones, with their profile if they have one
+func (s *UserService) GetUserWithProfile(id uint) (*models.User, *models.UserProfile, error) {
+	var user models.User
+	if err := s.db.Unscoped().First(&user, id).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var profile models.UserProfile
+	if err := s.db.Where("user_id = ?", id).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &user, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	return &user, &profile, nil
+}
+
+// ChangeRole assigns a new role. Live sessions are ended because access tokens carry the old role.
+func (s *UserService) ChangeRole(actorID, id uint, role string) (*models.User, error) {
+	if actorID == id {
+		return nil, ErrCannotModifySelf
+	}
+
+	exists, err := s.roleService.RoleExists(role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUnknownRole
+	}
+
+	var user models.User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+
+	if user.Role != role {
+		if err := s.db.Model(&user).Update("role", role).Error; err != nil {
+			return nil, err
+		}
+		user.Role = role
+		if err := s.authService.RevokeAllSessions(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// SetActive deactivates or reactivates an account. Deactivation ends all live sessions and
+// reactivation cancels a pending account deletion.
+func (s *UserService) SetActive(actorID, id uint, active bool) error {
+	if actorID == id && !active {
+		return ErrCannotModifySelf
+	}
+	if err := s.authService.SetUserActive(id, active); err != nil {
+		return err
+	}
+	if !active {
+		return nil
+	}
+
+	return s.db.Model(&models.AccountDeletion{}).
+		Where("user_id = ? AND completed_at IS NULL AND cancelled_at IS NULL", id).
+		Update("cancelled_at", time.Now()).Error
+}
+
+// Delete soft-deletes the user and ends all of their sessions
+func (s *UserService) Delete(actorID, id uint) error {
+	if actorID == id {
+		return ErrCannotModifySelf
+	}
+
+	result := s.db.Delete(&models.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return s.authService.RevokeAllSessions(id)
+}
+
+// Restore brings back a soft-deleted user
+func (s *UserService) Restore(id uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.Unscoped().First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	if !user.DeletedAt.Valid {
+		return nil, ErrUserNotDeleted
+	}
+
+	if err := s.db.Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+
+	user.DeletedAt = gorm.DeletedAt{}
+	return &user, nil
+}
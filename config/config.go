@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,88 @@ type Config struct {
 	REDIS_PASSWORD string
 	JWT_SECRET     string
 
+	// Access tokens are signed with RS256/EdDSA keys loaded from JWTKeysDir
+	JWTKeysDir             string
+	JWTKeyAlgorithm        string
+	JWTKeyRotationInterval time.Duration
+	JWTIssuer              string
+	JWTAudience            string
+
+	// Token lifetimes
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Bootstrap administrator account
+	AdminEmail    string
+	AdminPassword string
+
+	// Cookie sessions for the admin dashboard
+	AdminSessionTTL   time.Duration
+	AdminCookieSecure bool
+
+	// Links in emails point at the frontend
+	FrontendURL string
+
+	// Password reset
+	PasswordResetTTL time.Duration
+
+	// Password policy applied at register, reset and change
+	PasswordMinLength        int
+	PasswordMaxLength        int
+	PasswordRequireUpper     bool
+	PasswordRequireLower     bool
+	PasswordRequireDigit     bool
+	PasswordRequireSymbol    bool
+	PasswordBreachedListFile string
+
+	// Email verification
+	RequireEmailVerification   bool
+	EmailVerificationTTL       time.Duration
+	VerificationResendInterval time.Duration
+
+	// Brute-force protection for login
+	LoginMaxFailures      int
+	LoginMaxFailuresPerIP int
+	LoginFailureWindow    time.Duration
+	LoginLockoutDuration  time.Duration
+	LoginDelayThreshold   int
+	LoginDelayBase        time.Duration
+
+	// TOTP two-factor authentication
+	MFAIssuer        string
+	MFAPendingTTL    time.Duration
+	MFARequiredRoles []string
+
+	// OpenID Connect single sign-on
+	OIDCProviders       []OIDCProvider
+	OIDCRedirectBaseURL string
+	OIDCStateTTL        time.Duration
+
+	// AES-256-GCM keys for personal data at rest, as "version:base64key" pairs
+	FieldEncryptionKeys      []string
+	FieldEncryptionActiveKey int
+	FieldEncryptionIndexKey  string
+
+	// How long security events are kept
+	SecurityEventRetention time.Duration
+
+	// Lifetime of the token an admin gets when impersonating a user
+	ImpersonationTTL time.Duration
+
+	// Self-service account deletion ("anonymise" scrubs the row, "delete" removes it)
+	AccountDeletionGracePeriod time.Duration
+	AccountDeletionMode        string
+	AccountPurgeInterval       time.Duration
+
+	// Outgoing mail ("log" writes to MAIL_LOG_FILE or stdout, "smtp" uses the SMTP settings)
+	MailDriver   string
+	MailFrom     string
+	MailLogFile  string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
 	// Timeout configurations for high-performance bulk operations
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
@@ -32,8 +115,56 @@ type Config struct {
 	RedisPoolTimeout  time.Duration
 }
 
+// OIDCProvider configures login through an external OpenID Connect provider.
+// Each provider listed in OIDC_PROVIDERS reads its settings from OIDC_<NAME>_* variables.
+type OIDCProvider struct {
+	Name          string
+	IssuerURL     string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	Scopes        []string
+	GroupsClaim   string
+	RoleMapping   []OIDCRoleMapping
+	DefaultRole   string
+	AutoProvision bool
+
+	// DemoteUnmapped sets DefaultRole on users in none of the mapped groups. Without it, their
+	// role is left as it is.
+	DemoteUnmapped bool
+	// TrustedLinking lets the provider link its identities to existing accounts with
+	// permissions by email. Without it, only accounts whose role has no permissions are linked.
+	TrustedLinking bool
+}
+
+// OIDCRoleMapping grants Role to members of the IdP group Group. The first matching mapping wins.
+type OIDCRoleMapping struct {
+	Group string
+	Role  string
+}
+
 var AppConfig Config
 
+// OIDCProvider returns the configured provider with the given name
+func (c Config) OIDCProvider(name string) (OIDCProvider, bool) {
+	for _, provider := range c.OIDCProviders {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+	return OIDCProvider{}, false
+}
+
+// MFARequiredForRole reports whether users with the role must use two-factor authentication
+func (c Config) MFARequiredForRole(role string) bool {
+	for _, required := range c.MFARequiredRoles {
+		if required == role {
+			return true
+		}
+	}
+	return false
+}
+
 func LoadConfig() {
 	AppConfig = Config{
 		DB_URL:         os.Getenv("DATABASE_URL"),
@@ -41,6 +172,72 @@ func LoadConfig() {
 		REDIS_PASSWORD: os.Getenv("REDIS_PASSWORD"),
 		JWT_SECRET:     os.Getenv("JWT_SECRET"),
 
+		JWTKeysDir:             getStringEnv("JWT_KEYS_DIR", "./keys"),
+		JWTKeyAlgorithm:        getStringEnv("JWT_KEY_ALGORITHM", "EdDSA"),
+		JWTKeyRotationInterval: getDurationEnv("JWT_KEY_ROTATION_INTERVAL", 0),
+		JWTIssuer:              getStringEnv("JWT_ISSUER", "high-performance-api"),
+		JWTAudience:            getStringEnv("JWT_AUDIENCE", "high-performance-api"),
+
+		// Short-lived access tokens, long-lived rotating refresh tokens
+		AccessTokenTTL:  getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		AdminEmail:    os.Getenv("ADMIN_EMAIL"),
+		AdminPassword: os.Getenv("ADMIN_PASSWORD"),
+
+		AdminSessionTTL:   getDurationEnv("ADMIN_SESSION_TTL", 8*time.Hour),
+		AdminCookieSecure: getBoolEnv("ADMIN_COOKIE_SECURE", true),
+
+		FrontendURL: getStringEnv("FRONTEND_URL", "http://localhost:3000"),
+
+		PasswordResetTTL: getDurationEnv("PASSWORD_RESET_TTL", time.Hour),
+
+		PasswordMinLength:        getIntEnv("PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxLength:        getIntEnv("PASSWORD_MAX_LENGTH", 128),
+		PasswordRequireUpper:     getBoolEnv("PASSWORD_REQUIRE_UPPER", false),
+		PasswordRequireLower:     getBoolEnv("PASSWORD_REQUIRE_LOWER", false),
+		PasswordRequireDigit:     getBoolEnv("PASSWORD_REQUIRE_DIGIT", false),
+		PasswordRequireSymbol:    getBoolEnv("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordBreachedListFile: os.Getenv("PASSWORD_BREACHED_LIST_FILE"),
+
+		RequireEmailVerification:   getBoolEnv("REQUIRE_EMAIL_VERIFICATION", false),
+		EmailVerificationTTL:       getDurationEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		VerificationResendInterval: getDurationEnv("VERIFICATION_RESEND_INTERVAL", 2*time.Minute),
+
+		LoginMaxFailures:      getIntEnv("LOGIN_MAX_FAILURES", 10),
+		LoginMaxFailuresPerIP: getIntEnv("LOGIN_MAX_FAILURES_PER_IP", 50),
+		LoginFailureWindow:    getDurationEnv("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginLockoutDuration:  getDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginDelayThreshold:   getIntEnv("LOGIN_DELAY_THRESHOLD", 3),
+		LoginDelayBase:        getDurationEnv("LOGIN_DELAY_BASE", time.Second),
+
+		MFAIssuer:        getStringEnv("MFA_ISSUER", "High Performance API"),
+		MFAPendingTTL:    getDurationEnv("MFA_PENDING_TTL", 5*time.Minute),
+		MFARequiredRoles: getListEnv("MFA_REQUIRED_ROLES", nil),
+
+		OIDCRedirectBaseURL: getStringEnv("OIDC_REDIRECT_BASE_URL", "http://localhost:3000"),
+		OIDCStateTTL:        getDurationEnv("OIDC_STATE_TTL", 10*time.Minute),
+
+		FieldEncryptionKeys:      getListEnv("FIELD_ENCRYPTION_KEYS", nil),
+		FieldEncryptionActiveKey: getIntEnv("FIELD_ENCRYPTION_ACTIVE_KEY", 0),
+		FieldEncryptionIndexKey:  os.Getenv("FIELD_ENCRYPTION_INDEX_KEY"),
+
+		SecurityEventRetention: getDurationEnv("SECURITY_EVENT_RETENTION", 90*24*time.Hour),
+
+		ImpersonationTTL: getDurationEnv("IMPERSONATION_TTL", 30*time.Minute),
+
+		AccountDeletionGracePeriod: getDurationEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+		AccountDeletionMode:        getStringEnv("ACCOUNT_DELETION_MODE", "anonymise"),
+		AccountPurgeInterval:       getDurationEnv("ACCOUNT_PURGE_INTERVAL", time.Hour),
+
+		MailDriver:   getStringEnv("MAIL_DRIVER", "log"),
+		MailFrom:     getStringEnv("MAIL_FROM", "no-reply@localhost"),
+		MailLogFile:  os.Getenv("MAIL_LOG_FILE"),
+		SMTPHost:     getStringEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getIntEnv("SMTP_PORT", 1025),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
 		// HTTP Server timeouts - optimized for bulk uploads
 		ReadTimeout:  getDurationEnv("READ_TIMEOUT", 10*time.Minute),  // Increased to 10 minutes for large file reads
 		WriteTimeout: getDurationEnv("WRITE_TIMEOUT", 15*time.Minute), // Increased to 15 minutes for bulk operations
@@ -72,6 +269,8 @@ func LoadConfig() {
 		log.Fatal("JWT_SECRET environment variable is required")
 	}
 
+	AppConfig.OIDCProviders = loadOIDCProviders(AppConfig.OIDCRedirectBaseURL)
+
 	// Log timeout configurations
 	log.Printf("📊 Timeout Configuration:")
 	log.Printf("   HTTP Read Timeout: %v", AppConfig.ReadTimeout)
@@ -83,6 +282,13 @@ func LoadConfig() {
 }
 
 // Helper functions to parse environment variables with defaults
+func getStringEnv(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -93,6 +299,63 @@ func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+func getListEnv(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// loadOIDCProviders reads the providers named in OIDC_PROVIDERS. Providers without an
+// issuer or client ID are skipped with a warning.
+func loadOIDCProviders(redirectBaseURL string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range getListEnv("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		provider := OIDCProvider{
+			Name:          name,
+			IssuerURL:     os.Getenv(prefix + "ISSUER"),
+			ClientID:      os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret:  os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:   getStringEnv(prefix+"REDIRECT_URL", strings.TrimRight(redirectBaseURL, "/")+"/api/auth/oidc/"+name+"/callback"),
+			Scopes:        getListEnv(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+			GroupsClaim:   getStringEnv(prefix+"GROUPS_CLAIM", "groups"),
+			DefaultRole:   getStringEnv(prefix+"DEFAULT_ROLE", "user"),
+			AutoProvision: getBoolEnv(prefix+"AUTO_PROVISION", true),
+
+			DemoteUnmapped: getBoolEnv(prefix+"DEMOTE_UNMAPPED", false),
+			TrustedLinking: getBoolEnv(prefix+"TRUSTED_LINKING", false),
+		}
+
+		// ROLE_MAPPING is a list of group:role pairs, e.g. "platform-admins:admin,staff:user"
+		for _, pair := range getListEnv(prefix+"ROLE_MAPPING", nil) {
+			group, role, ok := strings.Cut(pair, ":")
+			if !ok || group == "" || role == "" {
+				log.Printf("Warning: Invalid role mapping %q for OIDC provider %s, expected group:role", pair, name)
+				continue
+			}
+			provider.RoleMapping = append(provider.RoleMapping, OIDCRoleMapping{Group: group, Role: role})
+		}
+
+		if provider.IssuerURL == "" || provider.ClientID == "" {
+			log.Printf("Warning: OIDC provider %s needs %sISSUER and %sCLIENT_ID, skipping it", name, prefix, prefix)
+			continue
+		}
+
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
 func getIntEnv(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -103,6 +366,16 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		log.Printf("Warning: Invalid boolean format for %s, using default: %t", key, defaultValue)
+	}
+	return defaultValue
+}
+
 func getInt64Env(key string, defaultValue int64) int64 {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
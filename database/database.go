@@ -87,12 +87,30 @@ func ConnectDB() {
 
 	log.Println("Redis Connected successfully")
 
+	// Run migrations that must happen before AutoMigrate
+	RunPreMigrations()
+
 	// Run migrations
 	err = DB.AutoMigrate(
 		&models.User{},
 		&models.UserProfile{},
+		&models.Address{},
+		&models.Role{},
+		&models.Permission{},
+		&models.RecoveryCode{},
+		&models.APIKey{},
+		&models.UserIdentity{},
+		&models.AccountDeletion{},
+		&models.SecurityEvent{},
+		&models.ImpersonationSession{},
 		&models.Category{},
+		&models.Brand{},
+		&models.BrandAlias{},
 		&models.Product{},
+		&models.ProductVariant{},
+		&models.ProductImage{},
+		&models.AttributeDefinition{},
+		&models.ProductAttributeValue{},
 	)
 	if err != nil {
 		log.Fatalf("Error AutoMigrate database: %v", err)
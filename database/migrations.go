@@ -2,6 +2,15 @@ package database
 
 import (
 	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
 )
 
 // RunMigrations handles database schema migrations
@@ -11,9 +20,425 @@ func RunMigrations() {
 	// Check if users table has 'name' column and migrate to 'first_name'/'last_name'
 	migrateUsersTable()
 
+	// Move the single profile address into the address book
+	migrateProfileAddresses()
+
+	// Move the single product image into the image gallery
+	migrateProductImages()
+
+	// Category names only need to be unique below the same parent
+	migrateCategoryTree()
+
+	// Link products to brand rows instead of free-text brand names
+	migrateBrands()
+
+	// Make sure the built-in roles and permissions exist
+	seedRolesAndPermissions()
+
+	// Bootstrap the first administrator account from configuration
+	seedAdminUser()
+
 	log.Println("Database migrations completed!")
 }
 
+// seedRolesAndPermissions creates the built-in roles and permissions. Permissions that
+// did not exist yet are granted to the admin role; later edits made through the API are kept.
+func seedRolesAndPermissions() {
+	var created []models.Permission
+	for name, description := range models.DefaultPermissions {
+		permission := models.Permission{Name: name, Description: description}
+		result := DB.Where("name = ?", name).FirstOrCreate(&permission)
+		if result.Error != nil {
+			log.Printf("Error creating permission %s: %v", name, result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			created = append(created, permission)
+		}
+	}
+
+	adminRole := models.Role{Name: models.RoleAdmin, Description: "Full access to the admin API"}
+	if err := DB.Where("name = ?", models.RoleAdmin).FirstOrCreate(&adminRole).Error; err != nil {
+		log.Printf("Error creating admin role: %v", err)
+		return
+	}
+
+	userRole := models.Role{Name: models.RoleUser, Description: "Regular customer account"}
+	if err := DB.Where("name = ?", models.RoleUser).FirstOrCreate(&userRole).Error; err != nil {
+		log.Printf("Error creating user role: %v", err)
+	}
+
+	if len(created) > 0 {
+		if err := DB.Model(&adminRole).Association("Permissions").Append(created); err != nil {
+			log.Printf("Error granting permissions to admin role: %v", err)
+			return
+		}
+		log.Printf("Granted %d new permissions to admin role", len(created))
+	}
+}
+
+// seedAdminUser creates or promotes the account configured through ADMIN_EMAIL/ADMIN_PASSWORD
+func seedAdminUser() {
+	email := config.AppConfig.AdminEmail
+	if email == "" {
+		return
+	}
+
+	var user models.User
+	if err := DB.Where("email = ?", email).First(&user).Error; err == nil {
+		if user.Role != models.RoleAdmin {
+			if err := DB.Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+				log.Printf("Error promoting %s to admin: %v", email, err)
+				return
+			}
+			log.Printf("Promoted %s to admin", email)
+		}
+		return
+	}
+
+	if config.AppConfig.AdminPassword == "" {
+		log.Printf("Warning: ADMIN_EMAIL is set but ADMIN_PASSWORD is empty, admin user not created")
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(config.AppConfig.AdminPassword)
+	if err != nil {
+		log.Printf("Error hashing admin password: %v", err)
+		return
+	}
+
+	user = models.User{
+		Email:     email,
+		Password:  hashedPassword,
+		FirstName: "Admin",
+		LastName:  "User",
+		Role:      models.RoleAdmin,
+		Active:    true,
+
+		EmailVerified: true,
+	}
+	if err := DB.Create(&user).Error; err != nil {
+		log.Printf("Error creating admin user: %v", err)
+		return
+	}
+	log.Printf("Created admin user %s", email)
+}
+
+// migrateProfileAddresses copies the address stored on user_profiles into the addresses table
+// as the default shipping and billing address, then drops the old columns
+func migrateProfileAddresses() {
+	var columnExists bool
+	err := DB.Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns 
+			WHERE table_name = 'user_profiles' AND column_name = 'address'
+		)
+	`).Scan(&columnExists).Error
+	if err != nil || !columnExists {
+		return
+	}
+
+	log.Println("Migrating profile addresses to the address book...")
+
+	type legacyAddress struct {
+		UserID     uint
+		Address    string
+		City       string
+		Country    string
+		PostalCode string
+		CreatedAt  time.Time
+	}
+
+	migrated := 0
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		var rows []legacyAddress
+		if err := tx.Raw(`
+			SELECT user_id, address, COALESCE(city, '') AS city, COALESCE(country, '') AS country,
+				COALESCE(postal_code, '') AS postal_code, created_at
+			FROM user_profiles
+			WHERE deleted_at IS NULL AND COALESCE(address, '') <> ''
+		`).Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			var existing int64
+			if err := tx.Model(&models.Address{}).Where("user_id = ?", row.UserID).Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				continue
+			}
+
+			// Free-text country names are mapped to codes; anything unrecognised is kept for manual review
+			country, ok := utils.NormalizeCountry(row.Country)
+			if !ok {
+				country = strings.TrimSpace(row.Country)
+				log.Printf("Warning: unrecognised country %q in the address of user %d", row.Country, row.UserID)
+			}
+
+			address := models.Address{
+				UserID:          row.UserID,
+				Label:           "Home",
+				Line1:           strings.TrimSpace(row.Address),
+				City:            strings.TrimSpace(row.City),
+				PostalCode:      utils.NormalizePostalCode(row.PostalCode),
+				Country:         country,
+				DefaultShipping: true,
+				DefaultBilling:  true,
+				CreatedAt:       row.CreatedAt,
+			}
+			if err := tx.Create(&address).Error; err != nil {
+				return err
+			}
+			migrated++
+		}
+
+		return tx.Exec(`
+			ALTER TABLE user_profiles 
+			DROP COLUMN address,
+			DROP COLUMN city,
+			DROP COLUMN country,
+			DROP COLUMN postal_code
+		`).Error
+	})
+	if err != nil {
+		log.Printf("Error migrating profile addresses: %v", err)
+		return
+	}
+
+	log.Printf("Migrated %d profile addresses", migrated)
+}
+
+// migrateProductImages adds the image of every product without a gallery as its primary image.
+// Products that already have gallery images are skipped, so the migration only does work once.
+func migrateProductImages() {
+	result := DB.Exec(`
+		INSERT INTO product_images (product_id, file, alt_text, position, is_primary, width, height, created_at, updated_at)
+		SELECT p.id, p.image, p.name, 0, true, 0, 0, NOW(), NOW()
+		FROM products p
+		WHERE COALESCE(p.image, '') <> ''
+			AND NOT EXISTS (SELECT 1 FROM product_images i WHERE i.product_id = p.id)
+	`)
+	if result.Error != nil {
+		log.Printf("Error migrating product images: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Migrated %d product images to the image gallery", result.RowsAffected)
+	}
+}
+
+// migrateCategoryTree makes category names unique among siblings, so the same name can be used
+// below different parents. Existing categories stay at the root of the tree.
+func migrateCategoryTree() {
+	err := DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_parent_name
+		ON categories (COALESCE(parent_id, 0), name) WHERE deleted_at IS NULL
+	`).Error
+	if err != nil {
+		log.Printf("Error creating category name index: %v", err)
+	}
+}
+
+// migrateBrands links products without a brand to brand rows. Spellings that only differ in
+// case, punctuation or a company form such as "Inc." share one brand, named after the most used
+// spelling, and every spelling is kept as an alias. Products get the brand's name.
+func migrateBrands() {
+	var spellings []struct {
+		Brand string
+		Count int64
+	}
+	err := DB.Unscoped().Model(&models.Product{}).
+		Select("brand, COUNT(*) AS count").
+		Where("brand_id IS NULL AND TRIM(brand) <> ''").
+		Group("brand").
+		Order("count DESC, brand").
+		Scan(&spellings).Error
+	if err != nil {
+		log.Printf("Error reading product brands: %v", err)
+		return
+	}
+	if len(spellings) == 0 {
+		return
+	}
+
+	var brands []models.Brand
+	var aliases []models.BrandAlias
+	if err := DB.Select("id, name").Find(&brands).Error; err != nil {
+		log.Printf("Error loading brands: %v", err)
+		return
+	}
+	if err := DB.Find(&aliases).Error; err != nil {
+		log.Printf("Error loading brand aliases: %v", err)
+		return
+	}
+	brandNames := make(map[uint]string, len(brands))
+	for _, brand := range brands {
+		brandNames[brand.ID] = brand.Name
+	}
+	brandByAlias := make(map[string]uint, len(aliases))
+	brandByKey := make(map[string]uint, len(aliases))
+	for _, alias := range aliases {
+		brandByAlias[alias.Alias] = alias.BrandID
+		if _, exists := brandByKey[alias.Key]; !exists {
+			brandByKey[alias.Key] = alias.BrandID
+		}
+	}
+
+	// Group the spellings by brand key, most used spelling first
+	var keys []string
+	groups := make(map[string][]string)
+	for _, spelling := range spellings {
+		key := utils.BrandKey(spelling.Brand)
+		if key == "" {
+			continue
+		}
+		if _, exists := groups[key]; !exists {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], spelling.Brand)
+	}
+
+	created, linked := 0, int64(0)
+	for _, key := range keys {
+		var newBrand *models.Brand
+		var newAliases []string
+		var groupLinked int64
+
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			brandID, exists := brandByKey[key]
+			brandName := brandNames[brandID]
+			if !exists {
+				name := utils.NormalizeBrandName(groups[key][0])
+				slugSource := name
+				if generateSlug(name) == "" {
+					slugSource = "brand"
+				}
+				newBrand = &models.Brand{Name: name, Slug: generateUniqueSlug(slugSource, "brands"), Active: true}
+				if err := tx.Omit("Aliases").Create(newBrand).Error; err != nil {
+					return err
+				}
+				brandID, brandName = newBrand.ID, newBrand.Name
+			}
+
+			for _, spelling := range groups[key] {
+				alias := utils.NormalizeBrandName(spelling)
+				target, targetName := brandID, brandName
+				if id, known := brandByAlias[alias]; known {
+					target, targetName = id, brandNames[id]
+				} else {
+					err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+						Create(&models.BrandAlias{BrandID: brandID, Alias: alias, Key: key}).Error
+					if err != nil {
+						return err
+					}
+					newAliases = append(newAliases, alias)
+				}
+
+				result := tx.Unscoped().Model(&models.Product{}).
+					Where("brand_id IS NULL AND brand = ?", spelling).
+					Updates(map[string]interface{}{"brand_id": target, "brand": targetName})
+				if result.Error != nil {
+					return result.Error
+				}
+				groupLinked += result.RowsAffected
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error linking products to brand '%s': %v", groups[key][0], err)
+			continue
+		}
+
+		brandID := brandByKey[key]
+		if newBrand != nil {
+			brandID = newBrand.ID
+			brandByKey[key] = brandID
+			brandNames[brandID] = newBrand.Name
+			created++
+		}
+		for _, alias := range newAliases {
+			brandByAlias[alias] = brandID
+		}
+		linked += groupLinked
+	}
+
+	log.Printf("Linked %d products to brands (%d brands created)", linked, created)
+}
+
+// RunPreMigrations handles schema changes that must run before AutoMigrate
+func RunPreMigrations() {
+	// Accounts created before email verification existed are treated as verified
+	migrateEmailVerifiedColumn()
+
+	// The global unique index on category names is replaced by one per parent category
+	dropCategoryNameUniqueIndex()
+}
+
+// dropCategoryNameUniqueIndex removes the unique index on categories.name. AutoMigrate keeps an
+// existing index of the same name, so it has to go before AutoMigrate recreates it as a plain index.
+func dropCategoryNameUniqueIndex() {
+	var unique bool
+	err := DB.Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM pg_indexes
+			WHERE tablename = 'categories' AND indexname = 'idx_categories_name' AND indexdef LIKE 'CREATE UNIQUE%'
+		)
+	`).Scan(&unique).Error
+	if err != nil || !unique {
+		return
+	}
+
+	if err := DB.Exec(`DROP INDEX idx_categories_name`).Error; err != nil {
+		log.Printf("Error dropping unique index on category names: %v", err)
+	}
+}
+
+// migrateEmailVerifiedColumn adds users.email_verified with existing rows marked as verified.
+// AutoMigrate would otherwise add the column with its default of false and lock legacy accounts out.
+func migrateEmailVerifiedColumn() {
+	var tableExists bool
+	err := DB.Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables 
+			WHERE table_name = 'users'
+		)
+	`).Scan(&tableExists).Error
+	if err != nil || !tableExists {
+		return
+	}
+
+	var columnExists bool
+	err = DB.Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns 
+			WHERE table_name = 'users' AND column_name = 'email_verified'
+		)
+	`).Scan(&columnExists).Error
+	if err != nil || columnExists {
+		return
+	}
+
+	log.Println("Adding 'email_verified' column to users table...")
+	err = DB.Exec(`
+		ALTER TABLE users 
+		ADD COLUMN email_verified BOOLEAN DEFAULT true,
+		ADD COLUMN email_verified_at TIMESTAMPTZ
+	`).Error
+	if err != nil {
+		log.Printf("Error adding 'email_verified' column: %v", err)
+		return
+	}
+
+	if err := DB.Exec(`UPDATE users SET email_verified_at = created_at`).Error; err != nil {
+		log.Printf("Error backfilling 'email_verified_at': %v", err)
+	}
+	if err := DB.Exec(`ALTER TABLE users ALTER COLUMN email_verified SET DEFAULT false`).Error; err != nil {
+		log.Printf("Error resetting 'email_verified' default: %v", err)
+	}
+}
+
 // migrateUsersTable migrates the users table from 'name' column to 'first_name'/'last_name' columns
 func migrateUsersTable() {
 	// Check if users table exists
@@ -176,6 +176,9 @@ func SeedProductsFromJSON() error {
 		}
 	}
 
+	// Seeded products only carry the brand name
+	migrateBrands()
+
 	log.Printf("Product seeding completed!")
 	log.Printf("📊 Final Results:")
 	log.Printf("  📂 Categories created: %d", categoriesCreated)
@@ -198,6 +201,8 @@ func generateUniqueSlug(name, table string) string {
 
 		if table == "categories" {
 			err = DB.Model(&models.Category{}).Where("slug = ?", slug).Count(&count).Error
+		} else if table == "brands" {
+			err = DB.Model(&models.Brand{}).Where("slug = ?", slug).Count(&count).Error
 		} else {
 			err = DB.Model(&models.Product{}).Where("slug = ?", slug).Count(&count).Error
 		}
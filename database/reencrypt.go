@@ -0,0 +1,90 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/models"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+// encryptedModels lists every model with encrypted columns
+var encryptedModels = []interface{}{
+	&models.User{},
+	&models.UserProfile{},
+	&models.Address{},
+}
+
+// ReencryptPersonalData rewrites every encrypted column that is still plaintext or was written
+// with an older key, in batches of batchSize rows. Rows are read with any key in the ring and
+// saved with the active one, so old keys can be removed once it has finished.
+func ReencryptPersonalData(batchSize int) (int64, error) {
+	prefix := utils.ActiveEncryptionKeyPrefix()
+	var rewritten int64
+
+	for _, model := range encryptedModels {
+		stmt := &gorm.Statement{DB: DB}
+		if err := stmt.Parse(model); err != nil {
+			return rewritten, err
+		}
+
+		var conditions []string
+		var args []interface{}
+		for _, field := range stmt.Schema.Fields {
+			if field.TagSettings["SERIALIZER"] != utils.EncryptedSerializer {
+				continue
+			}
+			column := stmt.Quote(field.DBName)
+			conditions = append(conditions, fmt.Sprintf("(%s <> '' AND %s NOT LIKE ?)", column, column))
+			args = append(args, prefix+"%")
+		}
+		if len(conditions) == 0 {
+			continue
+		}
+
+		rowType := reflect.TypeOf(model).Elem()
+		var lastID uint
+		var tableRewritten int64
+		for {
+			batch := reflect.New(reflect.SliceOf(rowType))
+			err := DB.Unscoped().Model(model).
+				Where("id > ?", lastID).
+				Where(strings.Join(conditions, " OR "), args...).
+				Order("id").Limit(batchSize).
+				Find(batch.Interface()).Error
+			if err != nil {
+				return rewritten, err
+			}
+
+			rows := batch.Elem()
+			if rows.Len() == 0 {
+				break
+			}
+
+			err = DB.Transaction(func(tx *gorm.DB) error {
+				for i := 0; i < rows.Len(); i++ {
+					row := rows.Index(i).Addr().Interface()
+					// Write every column back, but leave the timestamps alone
+					if err := tx.Unscoped().Model(row).Select("*").Omit("id", "created_at", "updated_at").Updates(row).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return rewritten, err
+			}
+
+			rewritten += int64(rows.Len())
+			tableRewritten += int64(rows.Len())
+			lastID = uint(rows.Index(rows.Len() - 1).FieldByName("ID").Uint())
+			log.Printf("Re-encrypted %d %s rows", tableRewritten, stmt.Schema.Table)
+		}
+	}
+
+	return rewritten, nil
+}
@@ -14,9 +14,11 @@ import (
 	fiberRecover "github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/joho/godotenv"
 
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
 	"github.com/rizkyizh/go-fiber-boilerplate/config"
 	"github.com/rizkyizh/go-fiber-boilerplate/database"
 	"github.com/rizkyizh/go-fiber-boilerplate/routes"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
 )
 
 // @title High Performance Go API with Assets Management
@@ -34,6 +36,10 @@ import (
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description Type "ApiKey" followed by a space and a service account API key.
 
 func main() {
 	// Initialize logger
@@ -48,11 +54,47 @@ func main() {
 	// Load configuration
 	config.LoadConfig()
 
+	// Load the access token signing keys
+	if err := services.InitJWTKeys(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	// Load the key ring used to encrypt personal data at rest
+	if err := utils.InitFieldEncryption(
+		config.AppConfig.FieldEncryptionKeys,
+		config.AppConfig.FieldEncryptionActiveKey,
+		config.AppConfig.FieldEncryptionIndexKey,
+	); err != nil {
+		log.Fatalf("Failed to load field encryption keys: %v", err)
+	}
+
 	// Connect to databases with retry logic
 	if err := connectWithRetry(); err != nil {
 		log.Fatalf("Failed to connect to databases after retries: %v", err)
 	}
 
+	// "reencrypt" rewrites personal data with the active encryption key and exits
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		rewritten, err := database.ReencryptPersonalData(500)
+		if err != nil {
+			log.Fatalf("Re-encryption failed after %d rows: %v", rewritten, err)
+		}
+		log.Printf("✅ Re-encrypted %d rows", rewritten)
+		closeDatabaseConnections()
+		return
+	}
+
+	// Refuse to start with OIDC role mappings that name unknown roles
+	if err := services.ValidateOIDCRoles(); err != nil {
+		log.Fatalf("Invalid OIDC configuration: %v", err)
+	}
+
+	// Purge accounts whose deletion grace period has passed
+	services.StartAccountPurger()
+
+	// Delete security events older than SECURITY_EVENT_RETENTION
+	services.StartSecurityEventPruner()
+
 	// Create Fiber app with enhanced configuration
 	app := createFiberApp()
 
@@ -1,14 +1,18 @@
 package middlewares
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+	"github.com/rizkyizh/go-fiber-boilerplate/database"
 )
 
 func AuthMiddleware() fiber.Handler {
+	apiKeyService := services.NewAPIKeyService()
+	sessionService := services.NewSessionService()
+
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -17,21 +21,70 @@ func AuthMiddleware() fiber.Handler {
 			})
 		}
 
-		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(config.AppConfig.JWT_SECRET), nil
-		})
+		// Service accounts authenticate with "Authorization: ApiKey <key>"
+		if rawKey, ok := strings.CutPrefix(authHeader, "ApiKey "); ok {
+			key, user, err := apiKeyService.Authenticate(strings.TrimSpace(rawKey))
+			if err != nil {
+				return c.Status(401).JSON(fiber.Map{
+					"error": "Invalid API key",
+				})
+			}
+
+			c.Locals("user_id", user.ID)
+			c.Locals("user_email", user.Email)
+			c.Locals("user_role", user.Role)
+			c.Locals("api_key_id", key.ID)
+			c.Locals("api_key_scopes", key.Scopes)
+
+			return c.Next()
+		}
+
+		tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+		if !ok {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Invalid token",
+			})
+		}
 
-		if err != nil || !token.Valid {
+		claims, err := services.GetJWTKeys().ParseAccessToken(tokenString)
+		if err != nil {
 			return c.Status(401).JSON(fiber.Map{
 				"error": "Invalid token",
 			})
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
-		c.Locals("user_id", uint(claims["user_id"].(float64)))
-		c.Locals("user_email", claims["email"].(string))
-		c.Locals("user_role", claims["role"].(string))
+		// Reject tokens whose server-side session was revoked by logout or deactivation
+		exists, err := database.Redis.Exists(c.Context(), "session:"+tokenString).Result()
+		if err != nil {
+			return c.Status(503).JSON(fiber.Map{
+				"error": "Session store unavailable",
+			})
+		}
+		if exists == 0 {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Session expired or revoked",
+			})
+		}
+
+		userID, _ := claims["user_id"].(float64)
+		email, _ := claims["email"].(string)
+		role, _ := claims["role"].(string)
+		c.Locals("user_id", uint(userID))
+		c.Locals("user_email", email)
+		c.Locals("user_role", role)
+		mfa, _ := claims["mfa"].(bool)
+		c.Locals("user_mfa", mfa)
+
+		// Impersonation tokens name the admin acting as the user; every response is flagged with it
+		if impersonatorID, ok := claims["impersonator_id"].(float64); ok {
+			c.Locals("impersonator_id", uint(impersonatorID))
+			c.Set(ImpersonatedByHeader, strconv.FormatUint(uint64(impersonatorID), 10))
+		}
+
+		if sessionID, _ := claims["sid"].(string); sessionID != "" {
+			c.Locals("session_id", sessionID)
+			sessionService.Touch(sessionID, c.IP())
+		}
 
 		return c.Next()
 	}
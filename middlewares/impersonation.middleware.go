@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImpersonatedByHeader is set on every response to a request made with an impersonation token
+const ImpersonatedByHeader = "X-Impersonated-By"
+
+// ForbidImpersonation blocks sensitive actions, such as changing the password or two-factor
+// settings, for admins acting as a user. It must be chained after AuthMiddleware.
+func ForbidImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := c.Locals("impersonator_id").(uint); ok {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "This action is not allowed while impersonating a user",
+			})
+		}
+		return c.Next()
+	}
+}
@@ -27,7 +27,11 @@ func PerformanceMonitor() fiber.Handler {
 		if statusCode == 0 && err != nil {
 			statusCode = 500
 		}
-		log.Printf("%s %s - %v - %d", c.Method(), c.Path(), duration, statusCode)
+		if impersonatorID, ok := c.Locals("impersonator_id").(uint); ok {
+			log.Printf("%s %s - %v - %d [user %v impersonated by admin %d]", c.Method(), c.Path(), duration, statusCode, c.Locals("user_id"), impersonatorID)
+		} else {
+			log.Printf("%s %s - %v - %d", c.Method(), c.Path(), duration, statusCode)
+		}
 
 		// Return the error from the handler chain
 		return err
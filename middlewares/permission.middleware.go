@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/rizkyizh/go-fiber-boilerplate/app/services"
+	"github.com/rizkyizh/go-fiber-boilerplate/config"
+	"github.com/rizkyizh/go-fiber-boilerplate/utils"
+)
+
+// RequirePermission only lets the request through when the authenticated user's role
+// has been granted the permission. Roles listed in MFA_REQUIRED_ROLES must also have
+// logged in with a second factor. Requests made with an API key must also carry the
+// permission in the key's scopes. It must be chained after AuthMiddleware.
+func RequirePermission(permission string) fiber.Handler {
+	roleService := services.NewRoleService()
+	responseHandler := &utils.ResponseHandler{}
+
+	return func(c *fiber.Ctx) error {
+		role, ok := c.Locals("user_role").(string)
+		if !ok || role == "" {
+			return responseHandler.Forbidden(c, []string{"No role assigned to the current user"})
+		}
+
+		scopes, isAPIKey := c.Locals("api_key_scopes").([]string)
+		if isAPIKey {
+			if !slices.Contains(scopes, permission) {
+				return responseHandler.Forbidden(c, []string{"API key is missing scope: " + permission})
+			}
+		} else if mfa, _ := c.Locals("user_mfa").(bool); !mfa && config.AppConfig.MFARequiredForRole(role) {
+			return responseHandler.Forbidden(c, []string{"Two-factor authentication is required for your role"})
+		}
+
+		allowed, err := roleService.HasPermission(role, permission)
+		if err != nil {
+			return responseHandler.InternalServerError(c, []string{"Failed to check permissions"})
+		}
+		if !allowed {
+			return responseHandler.Forbidden(c, []string{"Missing permission: " + permission})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// brandLegalSuffixes are company forms that do not tell brands apart, so "Apple Inc." and
+// "Apple" are the same brand
+var brandLegalSuffixes = map[string]bool{
+	"ag": true, "bv": true, "co": true, "company": true, "corp": true, "corporation": true,
+	"gmbh": true, "inc": true, "incorporated": true, "limited": true, "llc": true, "ltd": true,
+	"plc": true, "sa": true,
+}
+
+// NormalizeBrandName trims a brand name and collapses its whitespace
+func NormalizeBrandName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// BrandKey returns the key that identifies a brand however it is spelled: lowercase letters and
+// digits separated by single spaces, without a trailing company form. "Apple", "apple" and
+// "Apple Inc." all have the key "apple".
+func BrandKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for len(words) > 1 && brandLegalSuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
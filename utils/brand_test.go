@@ -0,0 +1,45 @@
+package utils
+
+import "testing"
+
+func TestNormalizeBrandName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Apple", "Apple"},
+		{"  Apple  ", "Apple"},
+		{"Hewlett   Packard", "Hewlett Packard"},
+		{"Dr.\tOetker\n", "Dr. Oetker"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeBrandName(tt.name); got != tt.want {
+			t.Errorf("NormalizeBrandName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBrandKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Apple", "apple"},
+		{"apple", "apple"},
+		{"Apple Inc.", "apple"},
+		{"APPLE, INC", "apple"},
+		{"Hewlett-Packard", "hewlett packard"},
+		{"Procter & Gamble Co.", "procter gamble"},
+		{"Siemens AG GmbH", "siemens"},
+		{"3M", "3m"},
+		// A company form alone is still a brand
+		{"Corp", "corp"},
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		if got := BrandKey(tt.name); got != tt.want {
+			t.Errorf("BrandKey(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// EncryptedSerializer is the name of the GORM serializer that encrypts a string column,
+// used as `gorm:"serializer:encrypted"`
+const EncryptedSerializer = "encrypted"
+
+// encryptedValuePrefix starts every encrypted value, followed by the key version:
+// "enc:v<version>:<base64 nonce+ciphertext>". Values without it are legacy plaintext.
+const encryptedValuePrefix = "enc:v"
+
+var ErrFieldEncryptionNotConfigured = errors.New("field encryption is not configured")
+
+type fieldKeyRing struct {
+	keys     map[int]cipher.AEAD
+	active   int
+	indexKey []byte
+}
+
+var fieldKeys *fieldKeyRing
+
+func init() {
+	schema.RegisterSerializer(EncryptedSerializer, encryptedSerializer{})
+}
+
+// InitFieldEncryption loads the AES-256-GCM key ring. Keys are "version:base64key" pairs; new
+// values are encrypted with activeVersion, or the highest version when it is 0. indexKey is
+// the base64 HMAC key for blind indexes.
+func InitFieldEncryption(keys []string, activeVersion int, indexKey string) error {
+	ring := &fieldKeyRing{keys: make(map[int]cipher.AEAD, len(keys))}
+
+	for _, entry := range keys {
+		versionStr, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || err != nil || version < 1 {
+			return fmt.Errorf("invalid encryption key %q, expected version:base64key", versionStr)
+		}
+		if _, exists := ring.keys[version]; exists {
+			return fmt.Errorf("duplicate encryption key version %d", version)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("encryption key version %d must be 32 bytes encoded as base64", version)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return err
+		}
+
+		ring.keys[version] = aead
+		ring.active = max(ring.active, version)
+	}
+
+	if len(ring.keys) == 0 {
+		return errors.New("no encryption keys configured")
+	}
+	if activeVersion != 0 {
+		if _, ok := ring.keys[activeVersion]; !ok {
+			return fmt.Errorf("active encryption key version %d is not in the key ring", activeVersion)
+		}
+		ring.active = activeVersion
+	}
+
+	var err error
+	ring.indexKey, err = base64.StdEncoding.DecodeString(indexKey)
+	if err != nil || len(ring.indexKey) < 32 {
+		return errors.New("blind index key must be at least 32 bytes encoded as base64")
+	}
+
+	fieldKeys = ring
+	return nil
+}
+
+// ActiveEncryptionKeyPrefix returns the prefix of values encrypted with the active key, so
+// values written with older keys can be found for re-encryption
+func ActiveEncryptionKeyPrefix() string {
+	if fieldKeys == nil {
+		return ""
+	}
+	return encryptedValuePrefix + strconv.Itoa(fieldKeys.active) + ":"
+}
+
+// EncryptField encrypts a value with the active key. The context is authenticated with the
+// value, so a ciphertext copied to another column fails to decrypt.
+func EncryptField(plaintext, context string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	if fieldKeys == nil {
+		return "", ErrFieldEncryptionNotConfigured
+	}
+
+	aead := fieldKeys.keys[fieldKeys.active]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(context))
+	return ActiveEncryptionKeyPrefix() + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptField decrypts a value written by EncryptField with any key in the ring. Values
+// without the encrypted prefix are returned as they are.
+func DecryptField(value, context string) (string, error) {
+	rest, ok := strings.CutPrefix(value, encryptedValuePrefix)
+	if !ok {
+		return value, nil
+	}
+	if fieldKeys == nil {
+		return "", ErrFieldEncryptionNotConfigured
+	}
+
+	versionStr, encoded, ok := strings.Cut(rest, ":")
+	version, err := strconv.Atoi(versionStr)
+	if !ok || err != nil {
+		return "", errors.New("malformed encrypted value")
+	}
+	aead, ok := fieldKeys.keys[version]
+	if !ok {
+		return "", fmt.Errorf("encryption key version %d is not in the key ring", version)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(context))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash of a normalised value, so encrypted columns can be matched
+// exactly without decrypting them. Empty values have an empty index.
+func BlindIndex(value string) string {
+	if value == "" || fieldKeys == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, fieldKeys.indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NormalizePhone keeps the digits of a phone number and a leading plus, for blind indexing
+func NormalizePhone(phone string) string {
+	phone = strings.TrimSpace(phone)
+
+	var b strings.Builder
+	for i, r := range phone {
+		if r >= '0' && r <= '9' || r == '+' && i == 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// encryptedSerializer encrypts string fields on write and decrypts them on read, using the
+// table and column as the authenticated context
+type encryptedSerializer struct{}
+
+func (encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted field %s", dbValue, field.Name)
+	}
+
+	plaintext, err := DecryptField(stored, encryptionContext(field))
+	if err != nil {
+		return fmt.Errorf("decrypt %s: %w", field.Name, err)
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (encryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s must be a string", field.Name)
+	}
+	return EncryptField(plaintext, encryptionContext(field))
+}
+
+func encryptionContext(field *schema.Field) string {
+	return field.Schema.Table + "." + field.DBName
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testEncryptionKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+// initTestEncryption loads a key ring for one test and removes it afterwards
+func initTestEncryption(t *testing.T, keys []string, active int) {
+	t.Helper()
+	if err := InitFieldEncryption(keys, active, testEncryptionKey('i')); err != nil {
+		t.Fatalf("InitFieldEncryption: %v", err)
+	}
+	t.Cleanup(func() { fieldKeys = nil })
+}
+
+func TestFieldEncryptionRoundTrip(t *testing.T) {
+	initTestEncryption(t, []string{"1:" + testEncryptionKey('a')}, 0)
+
+	tests := []string{"+49 30 1234567", "Straße 1", "a b", strings.Repeat("long ", 200)}
+	for _, plaintext := range tests {
+		encrypted, err := EncryptField(plaintext, "users.phone")
+		if err != nil {
+			t.Fatalf("EncryptField(%q): %v", plaintext, err)
+		}
+		if !strings.HasPrefix(encrypted, "enc:v1:") || strings.Contains(encrypted, plaintext) {
+			t.Errorf("EncryptField(%q) = %q, want an enc:v1: ciphertext", plaintext, encrypted)
+		}
+
+		decrypted, err := DecryptField(encrypted, "users.phone")
+		if err != nil {
+			t.Fatalf("DecryptField: %v", err)
+		}
+		if decrypted != plaintext {
+			t.Errorf("DecryptField = %q, want %q", decrypted, plaintext)
+		}
+	}
+
+	// A fresh nonce per value
+	first, _ := EncryptField("same", "users.phone")
+	second, _ := EncryptField("same", "users.phone")
+	if first == second {
+		t.Error("EncryptField returned the same ciphertext twice")
+	}
+}
+
+func TestFieldEncryptionEdgeCases(t *testing.T) {
+	initTestEncryption(t, []string{"1:" + testEncryptionKey('a')}, 0)
+
+	if encrypted, err := EncryptField("", "users.phone"); err != nil || encrypted != "" {
+		t.Errorf("EncryptField(\"\") = %q, %v, want an empty value", encrypted, err)
+	}
+	if plaintext, err := DecryptField("legacy plaintext", "users.phone"); err != nil || plaintext != "legacy plaintext" {
+		t.Errorf("DecryptField(plaintext) = %q, %v, want it unchanged", plaintext, err)
+	}
+
+	encrypted, _ := EncryptField("secret", "users.phone")
+	if _, err := DecryptField(encrypted, "addresses.phone"); err == nil {
+		t.Error("DecryptField accepted a value copied to another column")
+	}
+	sealed, _ := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(encrypted, "enc:v1:"))
+	sealed[len(sealed)-1] ^= 1
+	tampered := "enc:v1:" + base64.RawStdEncoding.EncodeToString(sealed)
+	if _, err := DecryptField(tampered, "users.phone"); err == nil {
+		t.Error("DecryptField accepted a tampered value")
+	}
+	for _, malformed := range []string{"enc:v1", "enc:vx:abc", "enc:v1:!!!", "enc:v1:AAAA"} {
+		if _, err := DecryptField(malformed, "users.phone"); err == nil {
+			t.Errorf("DecryptField(%q) accepted a malformed value", malformed)
+		}
+	}
+}
+
+func TestFieldEncryptionKeyVersions(t *testing.T) {
+	keyV1 := "1:" + testEncryptionKey('a')
+	keyV2 := "2:" + testEncryptionKey('b')
+
+	initTestEncryption(t, []string{keyV1}, 0)
+	old, err := EncryptField("secret", "users.mfa_secret")
+	if err != nil {
+		t.Fatalf("EncryptField: %v", err)
+	}
+
+	// After rotation, new values use the active key and old ones still decrypt
+	initTestEncryption(t, []string{keyV1, keyV2}, 2)
+	if prefix := ActiveEncryptionKeyPrefix(); prefix != "enc:v2:" {
+		t.Errorf("ActiveEncryptionKeyPrefix = %q, want enc:v2:", prefix)
+	}
+	rotated, _ := EncryptField("secret", "users.mfa_secret")
+	if !strings.HasPrefix(rotated, "enc:v2:") {
+		t.Errorf("EncryptField after rotation = %q, want an enc:v2: value", rotated)
+	}
+	if plaintext, err := DecryptField(old, "users.mfa_secret"); err != nil || plaintext != "secret" {
+		t.Errorf("DecryptField(v1 value) = %q, %v, want secret", plaintext, err)
+	}
+
+	// Without an explicit active version the highest one is used
+	initTestEncryption(t, []string{keyV2, keyV1}, 0)
+	if prefix := ActiveEncryptionKeyPrefix(); prefix != "enc:v2:" {
+		t.Errorf("ActiveEncryptionKeyPrefix = %q, want enc:v2:", prefix)
+	}
+
+	// Once the old key is removed, its values no longer decrypt
+	initTestEncryption(t, []string{keyV2}, 0)
+	if _, err := DecryptField(old, "users.mfa_secret"); err == nil {
+		t.Error("DecryptField decrypted a value whose key was removed")
+	}
+	if plaintext, err := DecryptField(rotated, "users.mfa_secret"); err != nil || plaintext != "secret" {
+		t.Errorf("DecryptField(v2 value) = %q, %v, want secret", plaintext, err)
+	}
+}
+
+func TestInitFieldEncryptionErrors(t *testing.T) {
+	t.Cleanup(func() { fieldKeys = nil })
+	valid := "1:" + testEncryptionKey('a')
+
+	tests := []struct {
+		name     string
+		keys     []string
+		active   int
+		indexKey string
+	}{
+		{"no keys", nil, 0, testEncryptionKey('i')},
+		{"missing version", []string{testEncryptionKey('a')}, 0, testEncryptionKey('i')},
+		{"version zero", []string{"0:" + testEncryptionKey('a')}, 0, testEncryptionKey('i')},
+		{"duplicate version", []string{valid, "1:" + testEncryptionKey('b')}, 0, testEncryptionKey('i')},
+		{"short key", []string{"1:" + base64.StdEncoding.EncodeToString([]byte("short"))}, 0, testEncryptionKey('i')},
+		{"unknown active version", []string{valid}, 2, testEncryptionKey('i')},
+		{"short index key", []string{valid}, 0, base64.StdEncoding.EncodeToString([]byte("short"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := InitFieldEncryption(tt.keys, tt.active, tt.indexKey); err == nil {
+				t.Error("InitFieldEncryption accepted an invalid configuration")
+			}
+		})
+	}
+
+	fieldKeys = nil
+	if _, err := EncryptField("secret", "users.phone"); err != ErrFieldEncryptionNotConfigured {
+		t.Errorf("EncryptField without keys = %v, want ErrFieldEncryptionNotConfigured", err)
+	}
+}